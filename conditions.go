@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// Condition types set on the composite resource.
+const (
+	typeSourcesResolved xpv1.ConditionType = "SourcesResolved"
+	typeMerged          xpv1.ConditionType = "Merged"
+	typeTargetSynced    xpv1.ConditionType = "TargetSynced"
+)
+
+// Condition reasons set on the composite resource.
+const (
+	reasonResolved          xpv1.ConditionReason = "Resolved"
+	reasonWaitingForSources xpv1.ConditionReason = "WaitingForSources"
+	reasonMerged            xpv1.ConditionReason = "Merged"
+	reasonSynced            xpv1.ConditionReason = "Synced"
+	reasonPending           xpv1.ConditionReason = "Pending"
+)
+
+// sourcesResolvedCondition indicates that the source resources were found, missing ones being skipped.
+func sourcesResolvedCondition(now time.Time, count, skipped int) xpv1.Condition {
+	message := fmt.Sprintf("Resolved %d source resources", count)
+	if skipped > 0 {
+		message = fmt.Sprintf("%s, skipped %d missing source references", message, skipped)
+	}
+	return condition(now, typeSourcesResolved, corev1.ConditionTrue, reasonResolved, message)
+}
+
+// sourcesWaitingCondition indicates that Crossplane has not yet fetched the source resources of a requirement.
+func sourcesWaitingCondition(now time.Time, requirement string) xpv1.Condition {
+	return condition(now, typeSourcesResolved, corev1.ConditionFalse, reasonWaitingForSources, fmt.Sprintf("Waiting for Crossplane to fetch the source resources of %s", requirement))
+}
+
+// mergedCondition indicates that the source resources were merged.
+func mergedCondition(now time.Time, count, keys int) xpv1.Condition {
+	return condition(now, typeMerged, corev1.ConditionTrue, reasonMerged, fmt.Sprintf("Merged %d source resources into %d keys", count, keys))
+}
+
+// targetSyncedCondition indicates that the target resource was written.
+func targetSyncedCondition(now time.Time, target string) xpv1.Condition {
+	return condition(now, typeTargetSynced, corev1.ConditionTrue, reasonSynced, fmt.Sprintf("Target resource %s is up to date", target))
+}
+
+// targetPendingCondition indicates that Crossplane has not yet created the desired target resource.
+func targetPendingCondition(now time.Time, target string) xpv1.Condition {
+	return condition(now, typeTargetSynced, corev1.ConditionFalse, reasonPending, fmt.Sprintf("Waiting for Crossplane to create the target resource %s", target))
+}
+
+func condition(now time.Time, ct xpv1.ConditionType, status corev1.ConditionStatus, reason xpv1.ConditionReason, message string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               ct,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(now),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// withObservedTransitions returns the conditions with the transition time of the observed condition of the same type
+// when its status and reason are unchanged, so that the time only changes when the condition transitions.
+func withObservedTransitions(observed *composite.Unstructured, conditions []xpv1.Condition) []xpv1.Condition {
+	out := make([]xpv1.Condition, 0, len(conditions))
+	for _, c := range conditions {
+		if o := observed.GetCondition(c.Type); o.Status == c.Status && o.Reason == c.Reason {
+			c.LastTransitionTime = o.LastTransitionTime
+		}
+		out = append(out, c)
+	}
+	return out
+}
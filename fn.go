@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
 
-	"dario.cat/mergo"
 	"github.com/pcanilho/crossplane-function-resources-merger/input/v1alpha1"
 	"github.com/pcanilho/crossplane-function-resources-merger/internal/k8s"
-	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
 	"github.com/pcanilho/crossplane-function-resources-merger/internal/merger"
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/redact"
 	"github.com/pcanilho/crossplane-function-resources-merger/internal/transformer"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/function-sdk-go"
+	fncontext "github.com/crossplane/function-sdk-go/context"
 	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
 	"github.com/crossplane/function-sdk-go/request"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
 	"github.com/crossplane/function-sdk-go/response"
 )
 
@@ -26,6 +34,8 @@ type Function struct {
 	fnv1beta1.UnimplementedFunctionRunnerServiceServer
 
 	log logging.Logger
+	// now returns the current time, defaults to time.Now.
+	now func() time.Time
 }
 
 // RunFunction runs the Function.
@@ -33,6 +43,10 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 	f.log.Info("Running function", "tag", req.GetMeta().GetTag())
 
 	rsp := response.To(req, response.DefaultTTL)
+	now := time.Now
+	if f.now != nil {
+		now = f.now
+	}
 
 	in := &v1alpha1.Input{}
 	if err := request.GetInput(req, in); err != nil {
@@ -50,6 +64,60 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 		return rsp, nil
 	}
 
+	switch in.Output {
+	case "", v1alpha1.OutputApply, v1alpha1.OutputDesired:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported output mode [%s]", in.Output))
+		return rsp, nil
+	}
+
+	switch in.Fetch {
+	case "", v1alpha1.FetchClient, v1alpha1.FetchExtraResources:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported fetch mode [%s]", in.Fetch))
+		return rsp, nil
+	}
+
+	switch in.OnLockedKeyOverride {
+	case "", v1alpha1.LockPolicyWarn, v1alpha1.LockPolicyFail:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported locked key override policy [%s]", in.OnLockedKeyOverride))
+		return rsp, nil
+	}
+
+	switch in.OnMissingSource {
+	case "", v1alpha1.MissingSourceFail, v1alpha1.MissingSourceSkip, v1alpha1.MissingSourceWarn:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported missing source policy [%s]", in.OnMissingSource))
+		return rsp, nil
+	}
+
+	switch in.ListMerge {
+	case "", v1alpha1.ListMergeConcatenate, v1alpha1.ListMergeUnique, v1alpha1.ListMergeKeyed:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported list merge mode [%s]", in.ListMerge))
+		return rsp, nil
+	}
+
+	switch in.ConflictPolicy {
+	case "", v1alpha1.ConflictPolicyAllow, v1alpha1.ConflictPolicyWarn, v1alpha1.ConflictPolicyFail:
+	default:
+		response.Fatal(rsp, errors.Errorf("unsupported conflict policy [%s]", in.ConflictPolicy))
+		return rsp, nil
+	}
+
+	redactor, err := redact.New(in.RedactKeys)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "cannot parse redaction patterns"))
+		return rsp, nil
+	}
+	// Redaction paths are relative to the merged data.
+	dataRedactor, err := redactor.WithPaths(in.RedactPaths...)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "cannot parse redaction paths"))
+		return rsp, nil
+	}
+
 	if in.SourceRefs == nil || len(in.SourceRefs) == 0 {
 		response.Fatal(rsp, errors.New("no resources to merge"))
 		return rsp, nil
@@ -68,32 +136,98 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 		f.log.Debug("Debug mode enabled")
 	}
 
-	f.log.Info("Running function...", "observed", xr.Resource.Object)
+	f.log.Info("Running function...", "observed", redactor.Redact(xr.Resource.Object))
 	mergoOpts, err := merger.ParseMergoOpts(xr)
 	if err != nil {
 		f.log.Info("Failed to parse mergo options from XR", "error", err)
 		response.Fatal(rsp, errors.Wrap(err, "cannot parse mergo options from XR"))
 		return rsp, nil
 	}
-	f.log.Info("Parsed merging options...", "options", maps.Keys(mergoOpts))
+	f.log.Info("Parsed merging options...", "options", mergoOpts.Names())
 
-	k8cCtl, err := k8s.NewController(k8s.WithTimeout(response.DefaultTTL))
-	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot create Kubernetes controller"))
+	// The Kubernetes controller is only required when talking to the API server directly.
+	var k8cCtl *k8s.Controller
+	if in.Fetch != v1alpha1.FetchExtraResources || in.Output != v1alpha1.OutputDesired {
+		k8cCtl, err = k8s.NewController(k8s.WithTimeout(response.DefaultTTL))
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot create Kubernetes controller"))
+			return rsp, nil
+		}
+	}
+
+	var reader k8s.Reader = k8cCtl
+	if in.Fetch == v1alpha1.FetchExtraResources {
+		// Requirements must be returned on every call, otherwise Crossplane stops fetching them.
+		rsp.Requirements = extraResourcesRequirements(in.SourceRefs)
+		extra, err := request.GetExtraResources(req)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot get extra resources from %T", req))
+			return rsp, nil
+		}
+		keys := make([]string, 0, len(rsp.GetRequirements().GetExtraResources()))
+		for key := range rsp.GetRequirements().GetExtraResources() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := extra[key]; !ok {
+				f.log.Info("Waiting for Crossplane to fetch the source resources...", "requirement", key)
+				if err := setCompositeStatus(req, rsp, nil, sourcesWaitingCondition(now(), key)); err != nil {
+					response.Fatal(rsp, errors.Wrap(err, "cannot write merge status"))
+				}
+				return rsp, nil
+			}
+		}
+		reader = k8s.NewExtraResources(extra)
+	}
+
+	var sources []source
+	var skipped int
+	for _, ref := range in.SourceRefs {
+		f.log.Debug("Attempting to find resources...", "GroupVersionKind", ref.Ref.GroupVersionKind(), "Name", ref.Ref.Name, "Namespace", ref.Namespace, "Selector", ref.Selector)
+		resolved, err := resolveSourceRef(ctx, reader, ref, in.TypeMeta)
+		if policy := missingSourcePolicy(ref, in.OnMissingSource); err != nil && isMissing(err) && policy != v1alpha1.MissingSourceFail {
+			f.log.Info("Skipped missing resourceRef...", "resourceRef", describeSourceRef(ref), "error", err)
+			if policy == v1alpha1.MissingSourceWarn {
+				response.Warning(rsp, errors.Wrapf(err, "skipped missing resourceRef: %s", describeSourceRef(ref)))
+			}
+			skipped++
+			continue
+		}
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "failed to find resourceRef: %s", describeSourceRef(ref)))
+			return rsp, nil
+		}
+		for _, res := range resolved {
+			sources = append(sources, source{ref: ref, resource: res})
+		}
+	}
+
+	if len(sources) == 0 {
+		response.Fatal(rsp, errors.New("no source resources found to merge"))
 		return rsp, nil
 	}
 
+	rules := mergeRules(in.Rules)
+	transformCtx := transformer.Context{Composite: xr.Resource.Object}
+	if env, ok := request.GetContextKey(req, fncontext.KeyEnvironment); ok {
+		transformCtx.Environment = env.GetStructValue().AsMap()
+	}
+	locks := merger.NewLocks()
+	tracker := merger.NewTracker()
+	secretSources := make(map[string]bool)
+	var conflicts []merger.Conflict
 	var mergedResource map[string]any
-	for _, ref := range in.SourceRefs {
-		f.log.Debug("Attempting to find resource...", "GroupVersionKind", ref.Ref.GroupVersionKind(), "Name", ref.Ref.Name, "Namespace", ref.Namespace)
-		res, err := k8cCtl.GetResource(ctx, ref.Namespace, ref.Ref.Name, ref.Ref.GroupVersionKind(), v1.GetOptions{
-			TypeMeta: in.TypeMeta,
-		})
+	var mergedList []any
+	for _, src := range sources {
+		ref := src.ref
+		sourceOpts, err := merger.WithSourceOpts(mergoOpts, ref.Options)
 		if err != nil {
-			response.Fatal(rsp, errors.Wrapf(err, "failed to find resourceRef: %s/%s", ref.Ref.Kind, ref.Ref.Name))
+			response.Fatal(rsp, errors.Wrapf(err, "cannot parse mergo options of resourceRef: %s", describeSourceRef(ref)))
 			return rsp, nil
 		}
-		uRes, err := runtime.DefaultUnstructuredConverter.ToUnstructured(res)
+		f.log.Debug("Processing resource...", "GroupVersionKind", src.resource.GroupVersionKind(), "Name", src.resource.GetName(), "Namespace", src.resource.GetNamespace())
+		uRes, err := runtime.DefaultUnstructuredConverter.ToUnstructured(src.resource)
 		if err != nil {
 			response.Fatal(rsp, errors.Wrap(err, "cannot convert resource to unstructured"))
 			return rsp, nil
@@ -103,56 +237,234 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 		if ref.Key != "" {
 			dataKey = ref.Key
 		}
-		if _, ok := uRes[dataKey]; !ok {
+		// Secrets may only hold stringData, which is merged alongside their decoded data.
+		if src.resource.GroupVersionKind() == secretGVK {
+			secretSources[src.String()] = true
+		}
+		secretData := src.resource.GroupVersionKind() == secretGVK && dataKey == "data"
+		_, hasStringData := uRes["stringData"]
+		value, err := fieldpath.Pave(uRes).GetValue(dataKey)
+		if err != nil && !fieldpath.IsNotFound(err) {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot get data from resource with key [%s]", dataKey))
+			return rsp, nil
+		}
+		if err != nil && !(secretData && hasStringData) {
 			response.Fatal(rsp, errors.New("resource is not merge-able as it does not have a data field"))
 			return rsp, nil
 		}
 
-		// transform
-		data, err := transformer.Transform(xr, uRes[dataKey].(map[string]any))
+		// JSON patch sources may hold a list of operations, only maps are transformed and extracted from
+		var data = value
+		if secretData {
+			encoded, _ := uRes["data"].(map[string]any)
+			stringData, _ := uRes["stringData"].(map[string]any)
+			decoded, err := transformer.DecodeSecretData(encoded, stringData)
+			if err != nil {
+				response.Fatal(rsp, errors.Wrapf(err, "cannot decode secret data of resource: %s", src))
+				return rsp, nil
+			}
+			data = decoded
+		}
+		data, err = merger.ParseSource(data)
 		if err != nil {
-			response.Fatal(rsp, errors.Wrap(err, "cannot transform resource data"))
+			response.Fatal(rsp, errors.Wrapf(err, "cannot merge resource: %s", src))
 			return rsp, nil
 		}
+		if dataMap, ok := data.(map[string]any); ok {
+			// transform
+			transformed, err := transformer.Transform(xr, dataMap)
+			if err != nil {
+				response.Fatal(rsp, errors.Wrap(err, "cannot transform resource data"))
+				return rsp, nil
+			}
+
+			// extract
+			if ref.ExtractFromKey != "" {
+				extracted, err := transformer.ExtractMapValue(transformed, ref.ExtractFromKey)
+				// if extraction is selected but fails, the merge function fails
+				if err != nil {
+					f.log.Info("Failed to extract data from resource", "error", err)
+					response.Fatal(rsp, errors.Wrapf(err, "cannot extract data from resource with key [%s]", ref.ExtractFromKey))
+					return rsp, nil
+				}
+				transformed = extracted
+			}
+			data = transformed
+		}
 
-		// extract
-		if ref.ExtractFromKey != "" {
-			extracted, err := transformer.ExtractMapValue(data, ref.ExtractFromKey)
-			// if extraction is selected but fails, the merge function fails
+		if ref.Extract != "" {
+			extracted, err := transformer.ExtractPath(data, ref.Extract)
 			if err != nil {
 				f.log.Info("Failed to extract data from resource", "error", err)
-				response.Fatal(rsp, errors.Wrapf(err, "cannot extract data from resource with key [%s]", ref.ExtractFromKey))
+				response.Fatal(rsp, errors.Wrapf(err, "cannot extract data from resource: %s", src))
 				return rsp, nil
 			}
 			data = extracted
 		}
 
-		if mergedResource == nil {
-			mergedResource = data
+		// transform steps
+		if len(ref.Transforms) > 0 {
+			transformed, err := transformer.Apply(transformCtx, data, transformSteps(ref.Transforms))
+			if err != nil {
+				response.Fatal(rsp, errors.Wrapf(err, "cannot transform data of resource: %s", src))
+				return rsp, nil
+			}
+			data = transformed
+		}
+
+		// filter keys
+		data, err = transformer.FilterKeys(data, ref.IncludeKeys, ref.ExcludeKeys)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot filter keys of resource: %s", src))
+			return rsp, nil
+		}
+
+		// rename keys
+		data, err = transformer.RenameKeys(data, ref.Rename, ref.KeyPrefix, ref.KeySuffix)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot rename keys of resource: %s", src))
+			return rsp, nil
+		}
+
+		// mount data
+		data, err = transformer.MountAt(data, ref.MountAt)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot mount data of resource: %s", src))
+			return rsp, nil
+		}
+
+		cfg := merger.Config{
+			Engine:        merger.Engine(in.Engine),
+			Options:       sourceOpts,
+			MergeKey:      in.MergeKey,
+			ListMergeKeys: in.ListMergeKeys,
+			Rules:         rules,
+		}
+		if ref.Engine != "" {
+			cfg.Engine = merger.Engine(ref.Engine)
+		}
+		if ref.MergeKey != "" {
+			cfg.MergeKey = ref.MergeKey
+		}
+
+		// Lists are merged on their own, except for JSON patch operations applied to the merged object.
+		if list, ok := data.([]any); ok && cfg.Engine != merger.EngineJSONPatch {
+			if mergedResource != nil {
+				response.Fatal(rsp, errors.Errorf("cannot merge list data of %s into object data", src))
+				return rsp, nil
+			}
+			f.log.Info("Merging list data [a←b]...")
+			mergedList, err = merger.MergeList(mergedList, list, merger.ListMode(in.ListMerge), cfg)
+			if err != nil {
+				response.Fatal(rsp, errors.Wrap(err, "cannot merge resources"))
+				return rsp, nil
+			}
 			continue
 		}
+		if mergedList != nil {
+			response.Fatal(rsp, errors.Errorf("cannot merge object data of %s into list data", src))
+			return rsp, nil
+		}
+
+		leaves := merger.Leaves(mergedResource)
+		found := tracker.Conflicts(leaves, data, src.String())
+		conflicts = append(conflicts, found...)
+		if in.ConflictPolicy == v1alpha1.ConflictPolicyFail && len(found) > 0 {
+			msgs := make([]string, len(found))
+			for i, conflict := range found {
+				msgs[i] = conflict.Error()
+			}
+			response.Fatal(rsp, errors.Errorf("cannot merge resources: %s", strings.Join(msgs, "; ")))
+			return rsp, nil
+		}
+		if in.ConflictPolicy == v1alpha1.ConflictPolicyWarn {
+			for _, conflict := range found {
+				f.log.Info("Detected conflicting values...", "path", conflict.Path, "owner", conflict.Owner, "resource", conflict.Source)
+				response.Warning(rsp, conflict)
+			}
+		}
 
-		existingData := mergedResource
-		toMergeData := data
 		f.log.Info("Merging data [a←b]...")
-		if mergeErr := mergo.Merge(&existingData, toMergeData, maps.Values(mergoOpts)...); mergeErr != nil {
-			response.Fatal(rsp, errors.Wrap(mergeErr, "cannot merge resources"))
+		f.log.Debug("Merging options...", "resource", src.resource.GetName(), "engine", cfg.Engine, "options", sourceOpts.Names())
+		merged, err := merger.Merge(mergedResource, data, cfg)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot merge resources"))
 			return rsp, nil
 		}
-		mergedResource = existingData
+		mergedResource = merged
+
+		for _, violation := range locks.Enforce(mergedResource, src.String()) {
+			if in.OnLockedKeyOverride == v1alpha1.LockPolicyFail {
+				response.Fatal(rsp, errors.Wrap(violation, "cannot merge resources"))
+				return rsp, nil
+			}
+			f.log.Info("Ignored locked key override...", "path", violation.Path, "owner", violation.Owner, "resource", violation.Source)
+			response.Warning(rsp, errors.Wrap(violation, "ignored locked key override"))
+		}
+		unmatched, err := locks.Add(mergedResource, ref.LockedKeys, src.String())
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot lock keys of resource: %s", src))
+			return rsp, nil
+		}
+		for _, path := range unmatched {
+			f.log.Info("Locked key not found...", "path", path, "resource", src.String())
+			response.Warning(rsp, errors.Errorf("locked key [%s] of %s matches no merged value", path, src))
+		}
+		tracker.Record(leaves, mergedResource, src.String())
 	}
 
 	target := in.TargetRef
 	gvk := target.Ref.GroupVersionKind()
 
+	var dataKey = "data"
+	if in.TargetRef.Key != "" {
+		dataKey = in.TargetRef.Key
+	}
+	configMapTarget := gvk.String() == "/v1, Kind=ConfigMap"
+	secretTarget := gvk == secretGVK && dataKey == "data"
+
+	// The merged data is either an object or a list
+	var mergedData any = mergedResource
+	if mergedList != nil {
+		mergedData = mergedList
+	}
+
+	// transform steps of the merged data
+	if len(in.Transforms) > 0 {
+		transformed, err := transformer.Apply(transformCtx, mergedData, transformSteps(in.Transforms))
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot transform merged data"))
+			return rsp, nil
+		}
+		mergedData = transformed
+		mergedResource, mergedList = nil, nil
+		switch d := transformed.(type) {
+		case map[string]any:
+			mergedResource = d
+		case []any:
+			mergedList = d
+		}
+	}
+
+	keys := len(mergedResource)
+	if mergedList != nil {
+		if configMapTarget || secretTarget {
+			response.Fatal(rsp, errors.Errorf("cannot write list data to %s target", gvk.Kind))
+			return rsp, nil
+		}
+		keys = len(mergedList)
+	}
+
 	// Conform with the v1.ConfigMap if selected
-	if gvk.String() == "/v1, Kind=ConfigMap" {
+	if configMapTarget {
 		mergedResource = transformer.TransformFromMap(mergedResource)
+		mergedData = mergedResource
 	}
 
-	var dataKey = "data"
-	if in.TargetRef.Key != "" {
-		dataKey = in.TargetRef.Key
+	// Secret data is stored base64 encoded
+	if secretTarget {
+		mergedResource = transformer.EncodeSecretData(mergedResource)
+		mergedData = mergedResource
 	}
 	runtimeObject := &unstructured.Unstructured{
 		Object: map[string]any{
@@ -163,12 +475,26 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 					"crossplane.io/external-name": target.Ref.Name,
 				},
 			},
-			dataKey: mergedResource,
 		},
 	}
 	runtimeObject.SetGroupVersionKind(gvk)
+	if err := fieldpath.Pave(runtimeObject.Object).SetValue(dataKey, mergedData); err != nil {
+		response.Fatal(rsp, errors.Wrapf(err, "cannot set data of target resource with key [%s]", dataKey))
+		return rsp, nil
+	}
 
-	if mode, err := xr.Resource.GetString("spec.mode"); err != nil || mode == "managed" {
+	if in.Provenance {
+		provenance, err := json.Marshal(tracker.Owners())
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot marshal merge provenance"))
+			return rsp, nil
+		}
+		runtimeObject.Object["metadata"].(map[string]any)["annotations"].(map[string]any)[v1alpha1.ProvenanceAnnotation] = string(provenance)
+	}
+
+	// Crossplane owns desired composed resources, so owner references are only set when applying directly.
+	mode, err := xr.Resource.GetString("spec.mode")
+	if in.Output != v1alpha1.OutputDesired && (err != nil || mode == "managed") {
 		runtimeObject.Object["metadata"].(map[string]any)["ownerReferences"] = []map[string]any{
 			{
 				"apiVersion":         xr.Resource.GetAPIVersion(),
@@ -181,39 +507,118 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 		}
 	}
 
-	_, err = k8cCtl.CreateResource(ctx, in.TargetRef.Namespace, runtimeObject, v1.CreateOptions{})
-	if err != nil {
-		response.Fatal(rsp, errors.Wrapf(err, "failed to create resource %s/%s", in.TargetRef.Namespace, in.TargetRef.Ref.Name))
+	targetCondition := targetSyncedCondition(now(), describeSourceRef(target))
+	switch in.Output {
+	case v1alpha1.OutputDesired:
+		desired, err := request.GetDesiredComposedResources(req)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot get desired resources from %T", req))
+			return rsp, nil
+		}
+
+		dc, err := composed.From(runtimeObject)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "unable to compose resource"))
+			return rsp, nil
+		}
+
+		// The target is only ready once Crossplane has written it and observes it.
+		observed, err := request.GetObservedComposedResources(req)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot get observed composed resources from %T", req))
+			return rsp, nil
+		}
+		ready, synced := resource.ReadyFalse, targetPendingCondition(now(), describeSourceRef(target))
+		if _, ok := observed[resource.Name(target.Ref.Name)]; ok {
+			ready, synced = resource.ReadyTrue, targetSyncedCondition(now(), describeSourceRef(target))
+		}
+		targetCondition = synced
+
+		desired[resource.Name(target.Ref.Name)] = &resource.DesiredComposed{Resource: dc, Ready: ready}
+		if err = response.SetDesiredComposedResources(rsp, desired); err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot set desired composed resources in %T", rsp))
+			return rsp, nil
+		}
+	default:
+		_, err = k8cCtl.CreateResource(ctx, in.TargetRef.Namespace, runtimeObject, v1.CreateOptions{})
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "failed to create resource %s/%s", in.TargetRef.Namespace, in.TargetRef.Ref.Name))
+			return rsp, nil
+		}
+	}
+	status := map[string]any{
+		"sources":        sourcesStatus(sources),
+		"keys":           int64(keys),
+		"target":         targetStatus(target),
+		"lastMergedTime": now().UTC().Format(time.RFC3339),
+	}
+	// An unsalted hash of Secret values would allow brute-forcing them offline from the XR status.
+	if gvk != secretGVK && len(secretSources) == 0 {
+		hash, err := dataHash(mergedData)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrap(err, "cannot hash merged data"))
+			return rsp, nil
+		}
+		status["hash"] = hash
+	}
+	if in.Status.Conflicts {
+		status["conflicts"] = conflictsStatus(conflicts)
+	}
+	if in.Status.Provenance {
+		status["provenance"] = provenanceStatus(tracker.Owners())
+	}
+	conditions := []xpv1.Condition{
+		sourcesResolvedCondition(now(), len(sources), skipped),
+		mergedCondition(now(), len(sources), keys),
+		targetCondition,
+	}
+	if err := setCompositeStatus(req, rsp, status, conditions...); err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "cannot write merge status"))
 		return rsp, nil
 	}
+
 	response.Normalf(rsp, "Successfully composed resource [name=%s] [resource=%s] [namespace=%s]", in.TargetRef.Ref.Name, in.TargetRef.Ref.GroupVersionKind(), in.TargetRef.Namespace)
-	f.log.Info("Successfully composed resources...", "resource", in.TargetRef.Ref.GroupVersionKind(), "namespace", in.TargetRef.Namespace)
-	f.log.Debug("Generation results", "resource", runtimeObject.Object)
+	f.log.Info("Successfully composed resources...", "resource", in.TargetRef.Ref.GroupVersionKind(), "namespace", in.TargetRef.Namespace, "output", in.Output)
+	// Values merged from Secrets are redacted alongside the configured paths. The whole data is redacted when the
+	// values merged from Secrets cannot be located: in Secret targets, in list data and once the merged data
+	// transforms, which may compute keys from them, ran.
+	fromSecrets := len(secretSources) > 0 && (mergedList != nil || len(in.Transforms) > 0)
+	generated := redactor.Redact(runtimeObject.Object)
+	switch {
+	case secretTarget || fromSecrets:
+		_ = fieldpath.Pave(generated).SetValue(dataKey, redact.Values(mergedData))
+	case mergedList == nil:
+		var secretPaths []string
+		for path, owner := range tracker.Owners() {
+			if secretSources[owner] {
+				secretPaths = append(secretPaths, path)
+			}
+		}
+		// Owned paths always parse as the tracker escapes the keys holding periods.
+		loggedData := redact.Values(mergedResource)
+		if secretRedactor, err := dataRedactor.WithPaths(secretPaths...); err == nil {
+			loggedData = secretRedactor.Redact(mergedResource)
+		}
+		_ = fieldpath.Pave(generated).SetValue(dataKey, loggedData)
+	}
+	f.log.Debug("Generation results", "resource", generated)
 	return rsp, nil
+}
 
-	// @TODO -> crossplane bug
-	// related: https://github.com/crossplane-contrib/provider-ansible/issues/172
-	// desired, err := request.GetDesiredComposedResources(req)
-	// if err != nil {
-	//	response.Fatal(rsp, errors.Wrapf(err, "cannot get desired resources from %T", req))
-	//	return rsp, nil
-	//}
-	//
-	// composed.Scheme.AddKnownTypeWithName(gvk, runtimeObject)
-	// dc, err := composed.From(runtimeObject)
-	// if err != nil {
-	//	response.Fatal(rsp, errors.Wrapf(err, "Unable to compose resource"))
-	//	return rsp, nil
-	//}
-	//
-	// rName := fmt.Sprintf("xmerger-%s", target.Ref.Name)
-	// desired[resource.Name(rName)] = &resource.DesiredComposed{Resource: dc}
-	// if err = response.SetDesiredComposedResources(rsp, desired); err != nil {
-	//	response.Fatal(rsp, errors.Wrapf(err, "cannot set desired composed resources in %T", rsp))
-	//	return rsp, nil
-	//}
-	// response.Normalf(rsp, "Successfully composed resource [external-name=%s] [resource=%s] [namespace=%s]", target.Ref.Name, in.TargetRef.Ref.GroupVersionKind(), in.TargetRef.Namespace)
-	// f.log.Info("Successfully composed resources...", "resource", in.TargetRef.Ref.GroupVersionKind(), "namespace", in.TargetRef.Namespace)
-	// f.log.Debug("Generation results", "resource", runtimeObject.Object)
-	// return rsp, nil
+// transformSteps converts the input transform steps to transformer steps.
+func transformSteps(steps []v1alpha1.TransformStep) []transformer.Step {
+	out := make([]transformer.Step, 0, len(steps))
+	for _, step := range steps {
+		out = append(out, transformer.Step{Name: step.Name, Args: step.Args})
+	}
+	return out
+}
+
+// mergeRules converts the input merge rules to merger policies.
+func mergeRules(rules map[string]v1alpha1.MergePolicy) map[string]merger.Policy {
+	out := make(map[string]merger.Policy, len(rules))
+	for path, policy := range rules {
+		out[path] = merger.Policy(policy)
+	}
+	return out
 }
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr/funcr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -107,6 +110,65 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"UnsupportedOutputMode": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "invalid",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"namespace": "ephemeral"
+						}
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "unsupported output mode [invalid]",
+						},
+					},
+				},
+			},
+		},
+		"InvalidRedactionPattern": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"redactKeys": ["("],
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						}
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot parse redaction patterns: invalid redaction pattern [(]: error parsing regexp: missing closing ): `(`",
+						},
+					},
+				},
+			},
+		},
 		"ResourceRefsNotFound": {
 			args: args{
 				ctx: context.Background(),
@@ -124,7 +186,3963 @@ func TestRunFunction(t *testing.T) {
 							{
 								"apiVersion": "v1",
 								"kind": "ConfigMap",
-								"name": "invalid-map",
+								"name": "invalid-map",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "failed to find resourceRef: ConfigMap/invalid-map: failed to get resource: the server could not find the requested resource",
+						},
+					},
+				},
+			},
+		},
+		"FoundAndMergedUnmanaged": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true,
+										"appendSlice": true,
+										"sliceDeepCopy": true
+									},
+									"mode": "unmanaged"
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Synced", "message": "Target resource ConfigMap/map-merged is up to date"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ExtraResourcesRequested": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"conditions": [
+										{"type": "SourcesResolved", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "WaitingForSources", "message": "Waiting for Crossplane to fetch the source resources of sourceRefs[0]"}
+									]
+								}
+							}`),
+						},
+					},
+				},
+			},
+		},
+		"ExtraResourcesNotFound": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "invalid-map",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "invalid-map"},
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  `failed to find resourceRef: ConfigMap/invalid-map: failed to get resource: ConfigMap "invalid-map" not found in extra resources`,
+						},
+					},
+				},
+			},
+		},
+		"AllSourcesSkipped": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"onMissingSource": "skip",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "invalid-map",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "invalid-map"},
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "no source resources found to merge",
+						},
+					},
+				},
+			},
+		},
+		"FoundAndMergedExtraResourcesDesired": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"FieldPathKeys": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "2.0", "pullPolicy": "Always"}}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "c578340c2c186f7a64e5aa9664de3d95fab58b6cc6b1670e2ca7c2d26715d151",
+										"keys": 1,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 1 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"SourcesMounted": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values",
+								"mountAt": "sources.map1"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values",
+								"mountAt": "sources.map2"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"sources": {"map1": {"image": {"tag": "1.0", "pullPolicy": "Always"}}, "map2": {"image": {"tag": "2.0"}}}}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "dfce17ba87356539d9fb580ea7e6ccf3ecce48ca0bf3f54cf2b99b560174f60b",
+										"keys": 1,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 1 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"KeysPrefixedAndRenamed": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values",
+								"keyPrefix": "db."
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values",
+								"keyPrefix": "cache.",
+								"rename": {"shared": "shared"}
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}, "shared": "yes"}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"db.image": {"tag": "1.0", "pullPolicy": "Always"}, "cache.image": {"tag": "2.0"}, "shared": "yes"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "e8d7a461495396579b357f61487a15b5de44ac79a446241ca9ecb97634f36d8c",
+										"keys": 3,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"KeysFiltered": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values",
+								"includeKeys": ["feature.*", "image"]
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values",
+								"excludeKeys": ["/^internal\\./"]
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}, "feature.a": "on", "other": "x"}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}, "internal.b": "secret"}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "2.0", "pullPolicy": "Always"}, "feature.a": "on"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d5ec227b2424a2891313a307386b520963fa951084336fad0a91480b9421e148",
+										"keys": 2,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 2 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"NoKeysIncluded": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true,
+										"overwriteEmptyValue": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values",
+								"includeKeys": ["feature.*"]
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0"}, "other": "x"}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "1.0"}, "other": "x"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "2cd44d193dfe0162e5a58d827dbc594728fa8e879203dd4307fa9b79cebd597d",
+										"keys": 2,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 2 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"CELComputedKeys": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"transforms": [{"name": "cel", "args": {"url": "\"https://\" + data.host + \":\" + string(data.port)"}}],
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}, "host": "db", "port": 5432}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "2.0", "pullPolicy": "Always"}, "host": "db", "port": 5432, "url": "https://db:5432"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "141aa4d052a4d21db9fc03c50a40a1925b71b0ffe2f4c8a87dd35e20fffc83cb",
+										"keys": 4,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 4 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"CELIntegerToConfigMap": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"transforms": [{"name": "cel", "args": {"maxConnections": "1000 * 1000"}}],
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d", "maxConnections": "1000000"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "f7d9ffbf33616fb14ae02552a53df90821803a08688caae9a48c8ffba4bfe37e",
+										"keys": 4,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 4 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ConditionTransitionKept": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								},
+								"status": {
+									"conditions": [
+										{"type": "SourcesResolved", "status": "False", "lastTransitionTime": "2024-07-01T12:00:00Z", "reason": "WaitingForSources", "message": "Waiting for Crossplane to fetch the source resources of sourceRefs[1]"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-07-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 2 keys"}
+									]
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-07-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"TransformSteps": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"transforms": [{"name": "mapToString"}],
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values",
+								"transforms": [{"name": "stringToMap"}]
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": "tag: \"1.0\"\npullPolicy: Always"}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": "pullPolicy: Always\ntag: \"2.0\"\n"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "cc9100bb9cfa7b85f5956eec81a36ee8d1516fb2eb5a92df02a8be30bf2ee083",
+										"keys": 1,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 1 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"TemplateRendered": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Context: resource.MustStructJSON(`{
+						"apiextensions.crossplane.io/environment": {"region": "eu-west-1"}
+					}`),
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"transforms": [{"name": "template"}],
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}, "host": "{{ .xr.metadata.name }}.{{ .environment.region }}"}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`{
+						"apiextensions.crossplane.io/environment": {"region": "eu-west-1"}
+					}`),
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "2.0", "pullPolicy": "Always"}, "host": "merger-results-xr.eu-west-1"}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "3f91081c814153ac5198dd29e16c89dead0e3f63e74c887dbcff75ced475e7be",
+										"keys": 2,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 2 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ExtractedByPath": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec",
+								"extract": ".items[?(@.name==\"app\")].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"name": "db", "values": {"ignored": true}}, {"name": "app", "values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"image": {"tag": "2.0", "pullPolicy": "Always"}}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "c578340c2c186f7a64e5aa9664de3d95fab58b6cc6b1670e2ca7c2d26715d151",
+										"keys": 1,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 1 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ExtractMatchedMultiple": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec",
+								"extract": ".items[*].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"name": "db", "values": {"ignored": true}}, {"name": "app", "values": {"image": {"tag": "2.0"}}}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot extract data from resource: Release/ephemeral/map-2: 2 values matched JSONPath expression [{.items[*].values}], expected exactly one",
+						},
+					},
+				},
+			},
+		},
+		"UnsupportedSourceData": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].values"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"image": {"tag": "1.0", "pullPolicy": "Always"}}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"values": {"ignored": true}}, {"values": true}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot merge resource: Release/ephemeral/map-2: unsupported source data of type bool: expected an object, a list or a string holding a YAML/JSON document",
+						},
+					},
+				},
+			},
+		},
+		"ListSourcesMergedUnique": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"listMerge": "unique",
+						"targetRef": {
+							"apiVersion": "helm.crossplane.io/v1beta1",
+							"kind": "Release",
+							"name": "map-merged",
+							"namespace": "ephemeral",
+							"key": "spec.forProvider.values.hosts"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"key": "spec.forProvider.values.hosts"
+							},
+							{
+								"apiVersion": "helm.crossplane.io/v1beta1",
+								"kind": "Release",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"key": "spec.items[1].hosts"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"spec": {"forProvider": {"values": {"hosts": ["a.example.com", "b.example.com"]}}}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "helm.crossplane.io/v1beta1",
+										"kind": "Release",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"spec": {"items": [{"hosts": ["ignored"]}, {"hosts": "[b.example.com, c.example.com]"}]}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "helm.crossplane.io/v1beta1",
+								Kind:       "Release",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "helm.crossplane.io/v1beta1",
+									"kind": "Release",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"spec": {"forProvider": {"values": {"hosts": ["a.example.com", "b.example.com", "c.example.com"]}}}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "20b0943a988459680bce759c11f37b8ab14c9209d1ac717efe1a7be9bb30bf87",
+										"keys": 3,
+										"target": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Release/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=helm.crossplane.io/v1beta1, Kind=Release] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"SecretsDecodedAndEncoded": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "Secret",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "Secret",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "Secret",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "Secret",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "YQ==", "key2": "YQ=="},
+										"stringData": {"key3": "s"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "Secret",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "Yw=="}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "Secret",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "Secret",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "Secret",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "YQ==", "key2": "Yw==", "key3": "cw=="}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "Secret", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "Secret", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "Secret", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource Secret/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=Secret] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"OptionalSourceSkipped": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-3",
+								"namespace": "ephemeral",
+								"optional": true
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[2]": {},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+							"sourceRefs[2]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-3"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources, skipped 1 missing source references"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  `skipped missing resourceRef: ConfigMap/map-3: failed to get resource: ConfigMap "map-3" not found in extra resources`,
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"TargetReadyWhenObserved": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral"},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+							},
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_TRUE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Synced", "message": "Target resource ConfigMap/map-merged is up to date"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"FoundAndMergedBySelectorInPriorityOrder": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"selector": {
+									"matchLabels": {"tier": "base"},
+									"matchExpressions": [{"key": "skip", "operator": "DoesNotExist"}]
+								},
+								"namespaceSelector": {
+									"matchLabels": {"env": "dev"}
+								},
+								"orderBy": "priority"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {
+											"name": "map-a",
+											"namespace": "ephemeral",
+											"resourceVersion": "201",
+											"labels": {"tier": "base"},
+											"annotations": {"resources-merger.fn.canilho.net/priority": "2"}
+										},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {
+											"name": "map-b",
+											"namespace": "ephemeral",
+											"resourceVersion": "202",
+											"labels": {"tier": "base"},
+											"annotations": {"resources-merger.fn.canilho.net/priority": "1"}
+										},
+										"data": {"key1": "b", "key3": "b"}
+									}`),
+								},
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {
+											"name": "map-c",
+											"namespace": "ephemeral",
+											"resourceVersion": "203",
+											"labels": {"tier": "base", "skip": "true"}
+										},
+										"data": {"key1": "c"}
+									}`),
+								},
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {
+											"name": "map-d",
+											"namespace": "production",
+											"resourceVersion": "204",
+											"labels": {"tier": "base"}
+										},
+										"data": {"key1": "d"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[0].namespaces": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "Namespace",
+										"metadata": {"name": "ephemeral", "labels": {"env": "dev"}}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match: &fnv1beta1.ResourceSelector_MatchLabels{
+									MatchLabels: &fnv1beta1.MatchLabels{Labels: map[string]string{"tier": "base"}},
+								},
+							},
+							"sourceRefs[0].namespaces": {
+								ApiVersion: "v1",
+								Kind:       "Namespace",
+								Match: &fnv1beta1.ResourceSelector_MatchLabels{
+									MatchLabels: &fnv1beta1.MatchLabels{Labels: map[string]string{"env": "dev"}},
+								},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "a", "key3": "b"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-b", "namespace": "ephemeral", "resourceVersion": "202"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-a", "namespace": "ephemeral", "resourceVersion": "201"}
+										],
+										"hash": "e818753f38461fe684f8bb622b0f1f7dfbf8359356e3a1a73f55c7e89e3f4ca4",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"FoundAndMergedWithSourceOptions": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral",
+								"options": {
+									"override": false
+								}
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "a", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "700900f0cb3857ce1b1bbd0655d27f91411d22274b454a604191006a6391d15b",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"UnknownMergeOption": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"overide": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot parse mergo options from XR: unknown merge option [overide]",
+						},
+					},
+				},
+			},
+		},
+		"LockedKeyOverrideIgnored": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"lockedKeys": ["key2"]
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "a", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "700900f0cb3857ce1b1bbd0655d27f91411d22274b454a604191006a6391d15b",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "ignored locked key override: ConfigMap/ephemeral/map-2 attempted to override locked key [key2] set by ConfigMap/ephemeral/map-1",
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"LockedKeysWithPeriods": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"lockedKeys": ["[db.host]", "db.port"]
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"db.host": "a", "db.port": "1"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"db.host": "c"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"db.host": "a", "db.port": "1"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "3e5b80db83e171a898bd442baa222b89902494cbf9dea744e700c594ea742bfa",
+										"keys": 2,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z"
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 2 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "locked key [db.port] of ConfigMap/ephemeral/map-1 matches no merged value",
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "ignored locked key override: ConfigMap/ephemeral/map-2 attempted to override locked key [[db.host]] set by ConfigMap/ephemeral/map-1",
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"LockedKeyOverrideFailed": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"onLockedKeyOverride": "fail",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral",
+								"lockedKeys": ["key2"]
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot merge resources: ConfigMap/ephemeral/map-2 attempted to override locked key [key2] set by ConfigMap/ephemeral/map-1",
+						},
+					},
+				},
+			},
+		},
+		"ConflictWarnedWithStatus": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"conflictPolicy": "warn",
+						"status": {"conflicts": true},
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z",
+										"conflicts": [
+											{"path": "key2", "sources": ["ConfigMap/ephemeral/map-1", "ConfigMap/ephemeral/map-2"]}
+										]
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "conflicting values for key [key2] set by ConfigMap/ephemeral/map-1 and ConfigMap/ephemeral/map-2",
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ConflictWarnedAcrossNamespaces": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"conflictPolicy": "warn",
+						"status": {"conflicts": true},
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "production"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "production", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {"name": "map-merged", "namespace": "ephemeral", "annotations": {"crossplane.io/external-name": "map-merged"}},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "production", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z",
+										"conflicts": [
+											{"path": "key2", "sources": ["ConfigMap/ephemeral/map-1", "ConfigMap/production/map-1"]}
+										]
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "conflicting values for key [key2] set by ConfigMap/ephemeral/map-1 and ConfigMap/production/map-1",
+						},
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ProvenanceRecorded": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"provenance": true,
+						"status": {"provenance": true},
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
+								"namespace": "ephemeral"
+							},
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-2",
+								"namespace": "ephemeral"
+							}
+						]
+					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Resources: map[string]*fnv1beta1.Resource{
+							"map-merged": {
+								Resource: resource.MustStructJSON(`{
+									"apiVersion": "v1",
+									"kind": "ConfigMap",
+									"metadata": {
+										"name": "map-merged",
+										"namespace": "ephemeral",
+										"annotations": {
+											"crossplane.io/external-name": "map-merged",
+											"resources-merger.fn.canilho.net/provenance": "{\"key1\":\"ConfigMap/ephemeral/map-1\",\"key2\":\"ConfigMap/ephemeral/map-2\",\"key4\":\"ConfigMap/ephemeral/map-2\"}"
+										}
+									},
+									"data": {"key1": "a", "key2": "c", "key4": "d"}
+								}`),
+								Ready: fnv1beta1.Ready_READY_FALSE,
+							},
+						},
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"status": {
+									"merge": {
+										"sources": [
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+											{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"}
+										],
+										"hash": "d6a497d94567558bce39df9afa37838354360b1816a8d601e563a428fa8fec55",
+										"keys": 3,
+										"target": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+										"lastMergedTime": "2024-08-01T12:00:00Z",
+										"provenance": {
+											"key1": "ConfigMap/ephemeral/map-1",
+											"key2": "ConfigMap/ephemeral/map-2",
+											"key4": "ConfigMap/ephemeral/map-2"
+										}
+									},
+									"conditions": [
+										{"type": "SourcesResolved", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Resolved", "message": "Resolved 2 source resources"},
+										{"type": "Merged", "status": "True", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Merged", "message": "Merged 2 source resources into 3 keys"},
+										{"type": "TargetSynced", "status": "False", "lastTransitionTime": "2024-08-01T12:00:00Z", "reason": "Pending", "message": "Waiting for Crossplane to create the target resource ConfigMap/map-merged"}
+									]
+								}
+							}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+						},
+					},
+				},
+			},
+		},
+		"ConflictFailed": {
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1beta1.RunFunctionRequest{
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{
+								"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+								"kind": "XR",
+								"metadata": {
+									"name": "merger-results-xr"
+								},
+								"spec": {
+									"options": {
+										"override": true
+									}
+								}
+							}`),
+						},
+					},
+					Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"conflictPolicy": "fail",
+						"targetRef": {
+							"apiVersion": "v1",
+							"kind": "ConfigMap",
+							"name": "map-merged",
+							"namespace": "ephemeral"
+						},
+						"sourceRefs": [
+							{
+								"apiVersion": "v1",
+								"kind": "ConfigMap",
+								"name": "map-1",
 								"namespace": "ephemeral"
 							},
 							{
@@ -135,21 +4153,61 @@ func TestRunFunction(t *testing.T) {
 							}
 						]
 					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key2": "c", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
 				},
 			},
 			want: want{
 				rsp: &fnv1beta1.RunFunctionResponse{
 					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
 					Results: []*fnv1beta1.Result{
 						{
 							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
-							Message:  "failed to find resourceRef: ConfigMap/invalid-map: failed to get resource: the server could not find the requested resource",
+							Message:  "cannot merge resources: conflicting values for key [key2] set by ConfigMap/ephemeral/map-1 and ConfigMap/ephemeral/map-2",
 						},
 					},
 				},
 			},
 		},
-		"FoundAndMergedUnmanaged": {
+		"ConflictsFailedTogether": {
 			args: args{
 				ctx: context.Background(),
 				req: &fnv1beta1.RunFunctionRequest{
@@ -163,11 +4221,8 @@ func TestRunFunction(t *testing.T) {
 								},
 								"spec": {
 									"options": {
-										"override": true,
-										"appendSlice": true,
-										"sliceDeepCopy": true
-									},
-									"mode": "unmanaged"
+										"override": true
+									}
 								}
 							}`),
 						},
@@ -176,6 +4231,9 @@ func TestRunFunction(t *testing.T) {
 					Input: resource.MustStructJSON(`{
 						"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
 						"kind": "Input",
+						"output": "desired",
+						"fetch": "extraResources",
+						"conflictPolicy": "fail",
 						"targetRef": {
 							"apiVersion": "v1",
 							"kind": "ConfigMap",
@@ -197,15 +4255,55 @@ func TestRunFunction(t *testing.T) {
 							}
 						]
 					}`),
+					ExtraResources: map[string]*fnv1beta1.Resources{
+						"sourceRefs[0]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+										"data": {"key1": "a", "key2": "a", "key3": "a"}
+									}`),
+								},
+							},
+						},
+						"sourceRefs[1]": {
+							Items: []*fnv1beta1.Resource{
+								{
+									Resource: resource.MustStructJSON(`{
+										"apiVersion": "v1",
+										"kind": "ConfigMap",
+										"metadata": {"name": "map-2", "namespace": "ephemeral", "resourceVersion": "102"},
+										"data": {"key1": "b", "key2": "c", "key3": "a", "key4": "d"}
+									}`),
+								},
+							},
+						},
+					},
 				},
 			},
 			want: want{
 				rsp: &fnv1beta1.RunFunctionResponse{
 					Meta: &fnv1beta1.ResponseMeta{Tag: "test", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1beta1.Requirements{
+						ExtraResources: map[string]*fnv1beta1.ResourceSelector{
+							"sourceRefs[0]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-1"},
+							},
+							"sourceRefs[1]": {
+								ApiVersion: "v1",
+								Kind:       "ConfigMap",
+								Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: "map-2"},
+							},
+						},
+					},
 					Results: []*fnv1beta1.Result{
 						{
-							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
-							Message:  "Successfully composed resource [name=map-merged] [resource=/v1, Kind=ConfigMap] [namespace=ephemeral]",
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "cannot merge resources: conflicting values for key [key1] set by ConfigMap/ephemeral/map-1 and ConfigMap/ephemeral/map-2; conflicting values for key [key2] set by ConfigMap/ephemeral/map-1 and ConfigMap/ephemeral/map-2",
 						},
 					},
 				},
@@ -215,11 +4313,8 @@ func TestRunFunction(t *testing.T) {
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			f := &Function{log: logging.NewNopLogger()}
+			f := &Function{log: logging.NewNopLogger(), now: func() time.Time { return time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC) }}
 			rsp, err := f.RunFunction(tc.args.ctx, tc.args.req)
-			if rsp != nil && rsp.GetDesired() != nil && rsp.GetDesired().GetResources() != nil {
-				delete(rsp.GetDesired().GetResources()["map-merged"].GetResource().GetFields(), "metadata")
-			}
 			if diff := cmp.Diff(tc.want.rsp, rsp, protocmp.Transform()); diff != "" {
 				t.Errorf("%s\nf.RunFunction(...): -want rsp, +got rsp:\n%s", tc.reason, diff)
 			}
@@ -230,3 +4325,209 @@ func TestRunFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestRunFunctionRedactsSecretSources(t *testing.T) {
+	req := &fnv1beta1.RunFunctionRequest{
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{
+					"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+					"kind": "XR",
+					"metadata": {"name": "merger-results-xr"}
+				}`),
+			},
+		},
+		Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+		Input: resource.MustStructJSON(`{
+			"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+			"kind": "Input",
+			"output": "desired",
+			"fetch": "extraResources",
+			"targetRef": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+			"sourceRefs": [
+				{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral"},
+				{"apiVersion": "v1", "kind": "Secret", "name": "certs", "namespace": "ephemeral"}
+			]
+		}`),
+		ExtraResources: map[string]*fnv1beta1.Resources{
+			"sourceRefs[0]": {
+				Items: []*fnv1beta1.Resource{{
+					Resource: resource.MustStructJSON(`{
+						"apiVersion": "v1",
+						"kind": "ConfigMap",
+						"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+						"data": {"tls.crt": "PUBLIC"}
+					}`),
+				}},
+			},
+			"sourceRefs[1]": {
+				Items: []*fnv1beta1.Resource{{
+					Resource: resource.MustStructJSON(`{
+						"apiVersion": "v1",
+						"kind": "Secret",
+						"metadata": {"name": "certs", "namespace": "ephemeral", "resourceVersion": "301"},
+						"data": {"tls.key": "UFJJVkFURQ==", ".dockerconfigjson": "Q1JFREVOVElBTFM="}
+					}`),
+				}},
+			},
+		},
+	}
+
+	rsp, out := runFunctionWithLogs(t, req)
+	data := rsp.GetDesired().GetResources()["map-merged"].GetResource().GetFields()["data"].GetStructValue().AsMap()
+	if diff := cmp.Diff(map[string]any{"tls.crt": "PUBLIC", "tls.key": "PRIVATE", ".dockerconfigjson": "CREDENTIALS"}, data); diff != "" {
+		t.Errorf("f.RunFunction(...): -want data, +got data:\n%s", diff)
+	}
+
+	merge := rsp.GetDesired().GetComposite().GetResource().GetFields()["status"].GetStructValue().GetFields()["merge"]
+	if _, ok := merge.GetStructValue().GetFields()["hash"]; ok {
+		t.Errorf("f.RunFunction(...): published the hash of data merged from a Secret")
+	}
+
+	for _, secret := range []string{"PRIVATE", "CREDENTIALS"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("f.RunFunction(...): logged Secret value %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{`"tls.key"="[REDACTED]"`, `".dockerconfigjson"="[REDACTED]"`, `"tls.crt"="PUBLIC"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("f.RunFunction(...): logs do not contain %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunFunctionRedactsSecretListSources(t *testing.T) {
+	req := &fnv1beta1.RunFunctionRequest{
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{
+					"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+					"kind": "XR",
+					"metadata": {"name": "merger-results-xr"}
+				}`),
+			},
+		},
+		Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+		Input: resource.MustStructJSON(`{
+			"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+			"kind": "Input",
+			"output": "desired",
+			"fetch": "extraResources",
+			"targetRef": {"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "map-merged", "namespace": "ephemeral", "key": "spec.items"},
+			"sourceRefs": [
+				{"apiVersion": "v1", "kind": "Secret", "name": "certs", "namespace": "ephemeral", "key": "stringData.items"}
+			]
+		}`),
+		ExtraResources: map[string]*fnv1beta1.Resources{
+			"sourceRefs[0]": {
+				Items: []*fnv1beta1.Resource{{
+					Resource: resource.MustStructJSON(`{
+						"apiVersion": "v1",
+						"kind": "Secret",
+						"metadata": {"name": "certs", "namespace": "ephemeral", "resourceVersion": "301"},
+						"stringData": {"items": "- hunter2\n- PRIVATE\n"}
+					}`),
+				}},
+			},
+		},
+	}
+
+	rsp, out := runFunctionWithLogs(t, req)
+	items := rsp.GetDesired().GetResources()["map-merged"].GetResource().GetFields()["spec"].GetStructValue().AsMap()["items"]
+	if diff := cmp.Diff([]any{"hunter2", "PRIVATE"}, items); diff != "" {
+		t.Errorf("f.RunFunction(...): -want items, +got items:\n%s", diff)
+	}
+	for _, secret := range []string{"hunter2", "PRIVATE"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("f.RunFunction(...): logged Secret value %q:\n%s", secret, out)
+		}
+	}
+	if want := `"items"=["[REDACTED]" "[REDACTED]"]`; !strings.Contains(out, want) {
+		t.Errorf("f.RunFunction(...): logs do not contain %s:\n%s", want, out)
+	}
+}
+
+func TestRunFunctionRedactsTransformedSecretSources(t *testing.T) {
+	req := &fnv1beta1.RunFunctionRequest{
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{
+					"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+					"kind": "XR",
+					"metadata": {"name": "merger-results-xr"}
+				}`),
+			},
+		},
+		Meta: &fnv1beta1.RequestMeta{Tag: "test"},
+		Input: resource.MustStructJSON(`{
+			"apiVersion": "resources-merger.fn.canilho.net/v1alpha1",
+			"kind": "Input",
+			"output": "desired",
+			"fetch": "extraResources",
+			"transforms": [{"name": "cel", "args": {"dsn": "\"pg://\" + data.user + \":\" + data.password + \"@h\""}}],
+			"targetRef": {"apiVersion": "v1", "kind": "ConfigMap", "name": "map-merged", "namespace": "ephemeral"},
+			"sourceRefs": [
+				{"apiVersion": "v1", "kind": "ConfigMap", "name": "map-1", "namespace": "ephemeral"},
+				{"apiVersion": "v1", "kind": "Secret", "name": "certs", "namespace": "ephemeral"}
+			]
+		}`),
+		ExtraResources: map[string]*fnv1beta1.Resources{
+			"sourceRefs[0]": {
+				Items: []*fnv1beta1.Resource{{
+					Resource: resource.MustStructJSON(`{
+						"apiVersion": "v1",
+						"kind": "ConfigMap",
+						"metadata": {"name": "map-1", "namespace": "ephemeral", "resourceVersion": "101"},
+						"data": {"user": "u"}
+					}`),
+				}},
+			},
+			"sourceRefs[1]": {
+				Items: []*fnv1beta1.Resource{{
+					Resource: resource.MustStructJSON(`{
+						"apiVersion": "v1",
+						"kind": "Secret",
+						"metadata": {"name": "certs", "namespace": "ephemeral", "resourceVersion": "301"},
+						"data": {"password": "aHVudGVyMg=="}
+					}`),
+				}},
+			},
+		},
+	}
+
+	rsp, out := runFunctionWithLogs(t, req)
+	data := rsp.GetDesired().GetResources()["map-merged"].GetResource().GetFields()["data"].GetStructValue().AsMap()
+	if diff := cmp.Diff(map[string]any{"user": "u", "password": "hunter2", "dsn": "pg://u:hunter2@h"}, data); diff != "" {
+		t.Errorf("f.RunFunction(...): -want data, +got data:\n%s", diff)
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("f.RunFunction(...): logged Secret value %q:\n%s", "hunter2", out)
+	}
+	for _, want := range []string{`"dsn"="[REDACTED]"`, `"user"="[REDACTED]"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("f.RunFunction(...): logs do not contain %s:\n%s", want, out)
+		}
+	}
+}
+
+// runFunctionWithLogs runs the Function with debug logging, failing on any result that is not normal, and returns the
+// response and the logs.
+func runFunctionWithLogs(t *testing.T, req *fnv1beta1.RunFunctionRequest) (*fnv1beta1.RunFunctionResponse, string) {
+	t.Helper()
+	var logs strings.Builder
+	log := funcr.New(func(_, args string) {
+		logs.WriteString(args + "\n")
+	}, funcr.Options{Verbosity: 1})
+
+	f := &Function{log: logging.NewLogrLogger(log), now: func() time.Time { return time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC) }}
+	rsp, err := f.RunFunction(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rsp.GetResults() {
+		if r.GetSeverity() != fnv1beta1.Severity_SEVERITY_NORMAL {
+			t.Fatalf("f.RunFunction(...): unexpected result: %s", r.GetMessage())
+		}
+	}
+	return rsp, logs.String()
+}
@@ -10,14 +10,189 @@ import (
 	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 )
 
+// PriorityAnnotation declares the merge priority of a resource matched by a selector when ordering by priority.
+const PriorityAnnotation = "resources-merger.fn.canilho.net/priority"
+
+// ProvenanceAnnotation holds the source that contributed each key of the merged data when provenance is enabled.
+const ProvenanceAnnotation = "resources-merger.fn.canilho.net/provenance"
+
+// OrderBy determines the merge order of the resources matched by a selector.
+// +kubebuilder:validation:Enum=name;priority;creationTimestamp
+type OrderBy string
+
+const (
+	// OrderByName merges the matched resources sorted by namespace and name.
+	OrderByName OrderBy = "name"
+	// OrderByPriority merges the matched resources sorted by ascending PriorityAnnotation value.
+	OrderByPriority OrderBy = "priority"
+	// OrderByCreationTimestamp merges the matched resources from oldest to newest.
+	OrderByCreationTimestamp OrderBy = "creationTimestamp"
+)
+
+// MergeEngine determines the algorithm used to merge a source into the merged document.
+// +kubebuilder:validation:Enum=mergo;jsonMergePatch;jsonPatch;strategic
+type MergeEngine string
+
+const (
+	// MergeEngineMergo deep merges sources using the merging options. (default)
+	MergeEngineMergo MergeEngine = "mergo"
+	// MergeEngineJSONMergePatch applies sources as RFC 7386 JSON merge patches where a null value deletes a key.
+	MergeEngineJSONMergePatch MergeEngine = "jsonMergePatch"
+	// MergeEngineJSONPatch applies sources as RFC 6902 JSON patch operations.
+	MergeEngineJSONPatch MergeEngine = "jsonPatch"
+	// MergeEngineStrategic merges lists of objects by MergeKey, Kubernetes strategic merge patch style.
+	MergeEngineStrategic MergeEngine = "strategic"
+)
+
+// MergePolicy determines how the value found at a path is merged.
+// +kubebuilder:validation:Enum=override;keepFirst;append
+type MergePolicy string
+
+const (
+	// MergePolicyOverride replaces the merged value with the value of the later source.
+	MergePolicyOverride MergePolicy = "override"
+	// MergePolicyKeepFirst keeps the value of the first source setting it.
+	MergePolicyKeepFirst MergePolicy = "keepFirst"
+	// MergePolicyAppend concatenates lists. Values that are not lists are overridden.
+	MergePolicyAppend MergePolicy = "append"
+)
+
+// ListMergeMode determines how sources holding lists are merged.
+// +kubebuilder:validation:Enum=concatenate;unique;keyed
+type ListMergeMode string
+
+const (
+	// ListMergeConcatenate appends the elements of later sources. (default)
+	ListMergeConcatenate ListMergeMode = "concatenate"
+	// ListMergeUnique appends the elements of later sources that are not already merged.
+	ListMergeUnique ListMergeMode = "unique"
+	// ListMergeKeyed matches objects by MergeKey, merging matching objects and appending new ones.
+	ListMergeKeyed ListMergeMode = "keyed"
+)
+
+// LockPolicy determines how attempts to override locked keys are handled.
+// +kubebuilder:validation:Enum=warn;fail
+type LockPolicy string
+
+const (
+	// LockPolicyWarn ignores the override and emits a warning result. (default)
+	LockPolicyWarn LockPolicy = "warn"
+	// LockPolicyFail aborts the merge with a fatal result.
+	LockPolicyFail LockPolicy = "fail"
+)
+
+// ConflictPolicy determines how sources setting the same key to different values are handled.
+// +kubebuilder:validation:Enum=allow;warn;fail
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyAllow merges conflicting values following the merge options. (default)
+	ConflictPolicyAllow ConflictPolicy = "allow"
+	// ConflictPolicyWarn merges conflicting values and emits a warning result for each conflict.
+	ConflictPolicyWarn ConflictPolicy = "warn"
+	// ConflictPolicyFail aborts the merge with a fatal result on the first conflicting source.
+	ConflictPolicyFail ConflictPolicy = "fail"
+)
+
+// MissingSourcePolicy determines how source resources that cannot be found are handled.
+// +kubebuilder:validation:Enum=fail;skip;warn
+type MissingSourcePolicy string
+
+const (
+	// MissingSourceFail aborts the merge with a fatal result. (default)
+	MissingSourceFail MissingSourcePolicy = "fail"
+	// MissingSourceSkip skips the source without reporting it.
+	MissingSourceSkip MissingSourcePolicy = "skip"
+	// MissingSourceWarn skips the source and emits a warning result.
+	MissingSourceWarn MissingSourcePolicy = "warn"
+)
+
+// StatusOptions selects the merge details written to the status of the composite resource.
+type StatusOptions struct {
+	// Conflicts writes the conflicting keys and their sources to status.merge.conflicts.
+	Conflicts bool `json:"conflicts,omitempty"`
+	// Provenance writes the source that contributed each key of the merged data to status.merge.provenance.
+	Provenance bool `json:"provenance,omitempty"`
+}
+
+// TransformStep is a transform applied to the data by name, e.g. `stringToMap`.
+type TransformStep struct {
+	// Name of the transform.
+	Name string `json:"name"`
+	// Args are the arguments of the transform.
+	Args map[string]string `json:"args,omitempty"`
+}
+
 // SourceRef is a reference to a Kubernetes resource.
 type SourceRef struct {
 	Ref            v1.TypedReference `json:",inline"`
 	Namespace      string            `json:"namespace,omitempty"`
 	ExtractFromKey string            `json:"extractFromKey,omitempty"`
 	Key            string            `json:"key,omitempty"`
+
+	// Extract selects the data to merge with a JSONPath expression, e.g. `{.spec.values}`, applied after
+	// ExtractFromKey. It must match exactly one object or list.
+	Extract string `json:"extract,omitempty"`
+
+	// Selector selects the resources to merge by label instead of by name.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// NamespaceSelector restricts the resources matched by Selector to the namespaces with matching labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// OrderBy determines the merge order of the resources matched by Selector. (defaults to name)
+	OrderBy OrderBy `json:"orderBy,omitempty"`
+
+	// Options overrides the XR merging options when merging this source.
+	Options map[string]bool `json:"options,omitempty"`
+	// Engine overrides the merge engine used to merge this source.
+	Engine MergeEngine `json:"engine,omitempty"`
+	// MergeKey overrides the field used to match list elements by the strategic engine.
+	MergeKey string `json:"mergeKey,omitempty"`
+	// Optional skips this source with a warning result when it cannot be found, instead of failing the merge.
+	Optional bool `json:"optional,omitempty"`
+
+	// LockedKeys lists the field paths of the merged data that later sources cannot change once merged. Keys holding
+	// periods are written between brackets, e.g. `[db.host]`.
+	LockedKeys []string `json:"lockedKeys,omitempty"`
+	// Transforms are applied in order to the data of this source, after its extraction and before merging it.
+	Transforms []TransformStep `json:"transforms,omitempty"`
+	// IncludeKeys keeps the top-level keys of the data of this source matching any of the patterns, after its
+	// transforms. Patterns are globs, e.g. `feature.*`, or regular expressions when wrapped in slashes.
+	IncludeKeys []string `json:"includeKeys,omitempty"`
+	// ExcludeKeys drops the top-level keys of the data of this source matching any of the patterns, after IncludeKeys.
+	ExcludeKeys []string `json:"excludeKeys,omitempty"`
+	// Rename maps top-level keys of the data of this source to their name in the merged data, after the key filters.
+	Rename map[string]string `json:"rename,omitempty"`
+	// KeyPrefix is added to the top-level keys of the data of this source that are not renamed.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// KeySuffix is added to the top-level keys of the data of this source that are not renamed.
+	KeySuffix string `json:"keySuffix,omitempty"`
+	// MountAt nests the data of this source under the field path of the merged data, e.g. `sources.a` or
+	// `sources[team.a]`, instead of merging it at the root.
+	MountAt string `json:"mountAt,omitempty"`
 }
 
+// OutputMode determines how the merged target resource is written.
+// +kubebuilder:validation:Enum=apply;desired
+type OutputMode string
+
+const (
+	// OutputApply creates or updates the target resource directly through the Kubernetes API.
+	OutputApply OutputMode = "apply"
+	// OutputDesired emits the target resource as a desired composed resource owned by Crossplane.
+	OutputDesired OutputMode = "desired"
+)
+
+// FetchMode determines how the source resources are retrieved.
+// +kubebuilder:validation:Enum=client;extraResources
+type FetchMode string
+
+const (
+	// FetchClient gets the source resources directly through the Kubernetes API.
+	FetchClient FetchMode = "client"
+	// FetchExtraResources requests the source resources from Crossplane as extra resources.
+	FetchExtraResources FetchMode = "extraResources"
+)
+
 // Input can be used to provide input to this Function.
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
@@ -26,7 +201,22 @@ type Input struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Debug      bool        `json:"debug,omitempty"`
-	TargetRef  SourceRef   `json:"targetRef"`
-	SourceRefs []SourceRef `json:"sourceRefs"`
+	Debug               bool                   `json:"debug,omitempty"`
+	Output              OutputMode             `json:"output,omitempty"`
+	Fetch               FetchMode              `json:"fetch,omitempty"`
+	Engine              MergeEngine            `json:"engine,omitempty"`
+	MergeKey            string                 `json:"mergeKey,omitempty"`
+	ListMergeKeys       map[string]string      `json:"listMergeKeys,omitempty"`
+	ListMerge           ListMergeMode          `json:"listMerge,omitempty"`
+	Rules               map[string]MergePolicy `json:"rules,omitempty"`
+	OnLockedKeyOverride LockPolicy             `json:"onLockedKeyOverride,omitempty"`
+	ConflictPolicy      ConflictPolicy         `json:"conflictPolicy,omitempty"`
+	OnMissingSource     MissingSourcePolicy    `json:"onMissingSource,omitempty"`
+	RedactKeys          []string               `json:"redactKeys,omitempty"`
+	RedactPaths         []string               `json:"redactPaths,omitempty"`
+	Provenance          bool                   `json:"provenance,omitempty"`
+	Status              StatusOptions          `json:"status,omitempty"`
+	Transforms          []TransformStep        `json:"transforms,omitempty"`
+	TargetRef           SourceRef              `json:"targetRef"`
+	SourceRefs          []SourceRef            `json:"sourceRefs"`
 }
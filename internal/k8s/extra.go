@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// Getter gets resources by their namespace, name and GroupVersionKind.
+type Getter interface {
+	GetResource(ctx context.Context, namespace, name string, resource schema.GroupVersionKind, opts metav1.GetOptions) (*unstructured.Unstructured, error)
+}
+
+// Lister lists resources by their GroupVersionKind and labels.
+type Lister interface {
+	ListResources(ctx context.Context, namespace string, resource schema.GroupVersionKind, selector labels.Selector) ([]*unstructured.Unstructured, error)
+}
+
+// Reader gets and lists resources.
+type Reader interface {
+	Getter
+	Lister
+}
+
+// ExtraResources serves resources that were fetched by Crossplane on behalf of the function.
+type ExtraResources struct {
+	resources map[string][]resource.Extra
+}
+
+// NewExtraResources creates a Reader backed by the extra resources supplied in a function request.
+func NewExtraResources(resources map[string][]resource.Extra) *ExtraResources {
+	return &ExtraResources{resources: resources}
+}
+
+// GetResource gets a resource from the extra resources supplied by Crossplane.
+// An empty namespace matches resources in any namespace.
+func (e *ExtraResources) GetResource(_ context.Context, namespace, name string, gvk schema.GroupVersionKind, _ metav1.GetOptions) (*unstructured.Unstructured, error) {
+	for _, extras := range e.resources {
+		for _, extra := range extras {
+			res := extra.Resource
+			if res.GroupVersionKind() != gvk || res.GetName() != name {
+				continue
+			}
+			if namespace != "" && res.GetNamespace() != "" && res.GetNamespace() != namespace {
+				continue
+			}
+			return res, nil
+		}
+	}
+	// Report a NotFound status so that missing extra resources are handled like missing API resources.
+	return nil, errors.Wrap(&kerrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusNotFound,
+		Reason:  metav1.StatusReasonNotFound,
+		Message: fmt.Sprintf("%s %q not found in extra resources", gvk.Kind, name),
+	}}, "failed to get resource")
+}
+
+// ListResources lists the extra resources supplied by Crossplane that match the selector.
+// An empty namespace matches resources in any namespace.
+func (e *ExtraResources) ListResources(_ context.Context, namespace string, gvk schema.GroupVersionKind, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	// The same resource may be supplied for several requirements.
+	seen := make(map[string]bool)
+	var out []*unstructured.Unstructured
+	for _, extras := range e.resources {
+		for _, extra := range extras {
+			res := extra.Resource
+			if res.GroupVersionKind() != gvk || !selector.Matches(labels.Set(res.GetLabels())) {
+				continue
+			}
+			if namespace != "" && res.GetNamespace() != namespace {
+				continue
+			}
+			id := res.GetNamespace() + "/" + res.GetName()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
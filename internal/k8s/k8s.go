@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -91,6 +92,35 @@ func (c *Controller) GetResource(ctx context.Context, namespace, name string, re
 	return res, nil
 }
 
+// ListResources lists the resources matching the selector in the Kubernetes cluster.
+// An empty namespace lists resources across all namespaces.
+func (c *Controller) ListResources(ctx context.Context, namespace string, resource schema.GroupVersionKind, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	if ctx == nil {
+		ctx = c.ctx
+	}
+
+	mapping, err := c.mapper.RESTMapping(schema.GroupKind{
+		Group: resource.Group,
+		Kind:  resource.Kind,
+	}, resource.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get REST mapping")
+	}
+
+	list, err := c.client.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list resources")
+	}
+
+	out := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		out = append(out, &list.Items[i])
+	}
+	return out, nil
+}
+
 // CreateResource creates a resource in the Kubernetes cluster.
 func (c *Controller) CreateResource(ctx context.Context, namespace string, resource *unstructured.Unstructured, opts metav1.CreateOptions) (*unstructured.Unstructured, error) {
 	gvk := resource.GroupVersionKind()
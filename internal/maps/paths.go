@@ -0,0 +1,41 @@
+package maps
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// SplitPath splits a field path, e.g. `db.host` or `data[tls.key]`, into the keys of the nested maps it addresses.
+// Keys holding periods are written between brackets.
+func SplitPath(path string) ([]string, error) {
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid path [%s]", path)
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("invalid path: empty path")
+	}
+	out := make([]string, 0, len(segments))
+	for _, s := range segments {
+		if s.Type == fieldpath.SegmentIndex {
+			out = append(out, strconv.FormatUint(uint64(s.Index), 10))
+			continue
+		}
+		out = append(out, s.Field)
+	}
+	return out, nil
+}
+
+// JoinPath appends the key to the field path, writing it between brackets when it holds a period, so that SplitPath
+// returns the original keys.
+func JoinPath(path, key string) string {
+	segment := fieldpath.Segments{fieldpath.Field(key)}.String()
+	if path == "" || strings.HasPrefix(segment, "[") {
+		return path + segment
+	}
+	return path + "." + segment
+}
@@ -0,0 +1,99 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitPath(t *testing.T) {
+	type want struct {
+		keys []string
+		err  string
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   want
+	}{
+		"Dotted": {
+			reason: "Periods should separate keys.",
+			path:   "db.host",
+			want:   want{keys: []string{"db", "host"}},
+		},
+		"Brackets": {
+			reason: "Keys between brackets should keep their periods.",
+			path:   "data[tls.key]",
+			want:   want{keys: []string{"data", "tls.key"}},
+		},
+		"LeadingPeriod": {
+			reason: "A key starting with a period should be kept whole.",
+			path:   "[.dockerconfigjson]",
+			want:   want{keys: []string{".dockerconfigjson"}},
+		},
+		"Empty": {
+			reason: "An empty path should be an error.",
+			want:   want{err: "invalid path: empty path"},
+		},
+		"Invalid": {
+			reason: "An invalid path should be an error.",
+			path:   "data[tls.key",
+			want:   want{err: "invalid path [data[tls.key]: unterminated '[' at position 4"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			keys, err := SplitPath(tc.path)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(tc.want.err, got); diff != "" {
+				t.Errorf("%s\nSplitPath(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.keys, keys); diff != "" {
+				t.Errorf("%s\nSplitPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		keys   []string
+		want   string
+	}{
+		"Plain": {
+			reason: "Plain keys should be separated by periods.",
+			keys:   []string{"db", "host"},
+			want:   "db.host",
+		},
+		"Periods": {
+			reason: "Keys holding periods should be written between brackets.",
+			keys:   []string{"db.host", "tls.key", ".dockerconfigjson"},
+			want:   "[db.host][tls.key][.dockerconfigjson]",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := ""
+			for _, k := range tc.keys {
+				path = JoinPath(path, k)
+			}
+			if diff := cmp.Diff(tc.want, path); diff != "" {
+				t.Errorf("%s\nJoinPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			keys, err := SplitPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.keys, keys); diff != "" {
+				t.Errorf("%s\nSplitPath(JoinPath(...)): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package merger
+
+import (
+	"encoding/json"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Engine is the algorithm used to merge a source into the merged document.
+type Engine string
+
+const (
+	// EngineMergo deep merges sources using mergo and the configured Options.
+	EngineMergo Engine = "mergo"
+	// EngineJSONMergePatch applies sources as RFC 7386 JSON merge patches. A null value deletes a key.
+	EngineJSONMergePatch Engine = "jsonMergePatch"
+	// EngineJSONPatch applies sources as RFC 6902 JSON patch operations.
+	EngineJSONPatch Engine = "jsonPatch"
+	// EngineStrategic deep merges sources following Kubernetes strategic merge patch semantics.
+	// Lists of objects are merged by MergeKey and a null value deletes a key.
+	EngineStrategic Engine = "strategic"
+)
+
+// DefaultMergeKey is the field used to match list elements by the strategic engine.
+const DefaultMergeKey = "name"
+
+// Config configures how a source is merged.
+type Config struct {
+	Engine   Engine
+	Options  Options
+	MergeKey string
+	// ListMergeKeys maps the paths of lists merged by the mergo engine to the key matching their elements.
+	ListMergeKeys map[string]string
+	// Rules maps paths to the policy merging their values, taking precedence over the engine.
+	// Rules apply to the mergo and strategic engines.
+	Rules map[string]Policy
+}
+
+// Merge merges the source into the destination document and returns the result.
+// The source of a jsonPatch merge is a list of operations, a JSON/YAML string holding one, or a
+// map with a single entry holding either of them.
+func Merge(dst map[string]any, src any, cfg Config) (map[string]any, error) {
+	if dst == nil {
+		dst = make(map[string]any)
+	}
+
+	switch cfg.Engine {
+	case "", EngineMergo:
+		srcMap, ok := src.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf("%s engine cannot merge source of type %T", EngineMergo, src)
+		}
+		pruned, err := applyRules(dst, srcMap, cfg.Rules)
+		if err != nil {
+			return nil, err
+		}
+		return mergeMergo(dst, pruned, cfg, "")
+	case EngineJSONMergePatch:
+		return mergeJSONMergePatch(dst, src)
+	case EngineJSONPatch:
+		return mergeJSONPatch(dst, src)
+	case EngineStrategic:
+		srcMap, ok := src.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf("%s engine cannot merge source of type %T", EngineStrategic, src)
+		}
+		srcMap, err := applyRules(dst, srcMap, cfg.Rules)
+		if err != nil {
+			return nil, err
+		}
+		key := cfg.MergeKey
+		if key == "" {
+			key = DefaultMergeKey
+		}
+		return mergeStrategic(dst, srcMap, key), nil
+	default:
+		return nil, errors.Errorf("unsupported merge engine [%s]", cfg.Engine)
+	}
+}
+
+func mergeJSONMergePatch(dst map[string]any, src any) (map[string]any, error) {
+	doc, err := json.Marshal(dst)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal merged document")
+	}
+	patch, err := json.Marshal(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal merge patch")
+	}
+	out, err := jsonpatch.MergePatch(doc, patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot apply merge patch")
+	}
+	return unmarshalDocument(out)
+}
+
+func mergeJSONPatch(dst map[string]any, src any) (map[string]any, error) {
+	// Allow the operations to be held by a single key, e.g. a ConfigMap data entry.
+	if m, ok := src.(map[string]any); ok && len(m) == 1 {
+		for _, v := range m {
+			src = v
+		}
+	}
+
+	var ops any
+	switch s := src.(type) {
+	case []any:
+		ops = s
+	case string:
+		if err := yaml.NewDecoder(strings.NewReader(s)).Decode(&ops); err != nil {
+			return nil, errors.Wrap(err, "cannot decode JSON patch operations")
+		}
+	default:
+		return nil, errors.Errorf("%s engine cannot merge source of type %T", EngineJSONPatch, src)
+	}
+
+	raw, err := json.Marshal(ops)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal JSON patch operations")
+	}
+	patch, err := jsonpatch.DecodePatch(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode JSON patch operations")
+	}
+	doc, err := json.Marshal(dst)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal merged document")
+	}
+	out, err := patch.Apply(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot apply JSON patch")
+	}
+	return unmarshalDocument(out)
+}
+
+// ParseSource returns the source data as an object or a list, decoding strings holding a YAML/JSON document.
+func ParseSource(src any) (any, error) {
+	switch s := src.(type) {
+	case map[string]any, []any:
+		return s, nil
+	case string:
+		raw, err := sigsyaml.YAMLToJSON([]byte(s))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode string as a YAML/JSON document")
+		}
+		var out any
+		if err := utiljson.Unmarshal(raw, &out); err != nil {
+			return nil, errors.Wrap(err, "cannot decode string as a YAML/JSON document")
+		}
+		switch out.(type) {
+		case map[string]any, []any:
+			return out, nil
+		default:
+			return nil, errors.Errorf("unsupported source data: string does not hold an object or a list")
+		}
+	default:
+		return nil, errors.Errorf("unsupported source data of type %T: expected an object, a list or a string holding a YAML/JSON document", src)
+	}
+}
+
+// unmarshalDocument decodes a JSON document keeping integers as int64, as unstructured objects do.
+func unmarshalDocument(data []byte) (map[string]any, error) {
+	out := make(map[string]any)
+	if err := utiljson.Unmarshal(data, &out); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal merged document")
+	}
+	return out, nil
+}
@@ -0,0 +1,221 @@
+package merger
+
+import (
+	"testing"
+
+	"dario.cat/mergo"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMerge(t *testing.T) {
+	type args struct {
+		dst map[string]any
+		src any
+		cfg Config
+	}
+	type want struct {
+		out map[string]any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"MergoIntoEmpty": {
+			reason: "Merging into an empty document should return the source.",
+			args: args{
+				src: map[string]any{"a": "1"},
+			},
+			want: want{out: map[string]any{"a": "1"}},
+		},
+		"MergoNonMapSource": {
+			reason: "The mergo engine should reject sources that are not maps.",
+			args: args{
+				dst: map[string]any{"a": "1"},
+				src: []any{"a"},
+			},
+			want: want{err: "mergo engine cannot merge source of type []interface {}"},
+		},
+		"MergoEmptySource": {
+			reason: "Merging an empty source should leave the document untouched, even when overwriting empty values.",
+			args: args{
+				dst: map[string]any{"a": "1"},
+				src: map[string]any{},
+				cfg: Config{Options: Options{
+					{Name: "override", Apply: mergo.WithOverride},
+					{Name: "overwriteEmptyValue", Apply: mergo.WithOverwriteWithEmptyValue},
+				}},
+			},
+			want: want{out: map[string]any{"a": "1"}},
+		},
+		"JSONMergePatchDeletesKeys": {
+			reason: "A null value in a JSON merge patch should delete the key.",
+			args: args{
+				dst: map[string]any{"a": "1", "b": map[string]any{"c": "2", "d": "3"}},
+				src: map[string]any{"a": nil, "b": map[string]any{"c": "4", "d": nil}},
+				cfg: Config{Engine: EngineJSONMergePatch},
+			},
+			want: want{out: map[string]any{"b": map[string]any{"c": "4"}}},
+		},
+		"JSONPatchOperations": {
+			reason: "JSON patch operations should be applied in order.",
+			args: args{
+				dst: map[string]any{"a": "1", "list": []any{"x", "y"}, "n": int64(1)},
+				src: []any{
+					map[string]any{"op": "remove", "path": "/a"},
+					map[string]any{"op": "add", "path": "/list/-", "value": "z"},
+					map[string]any{"op": "replace", "path": "/n", "value": 2},
+				},
+				cfg: Config{Engine: EngineJSONPatch},
+			},
+			want: want{out: map[string]any{"list": []any{"x", "y", "z"}, "n": int64(2)}},
+		},
+		"JSONPatchFromString": {
+			reason: "JSON patch operations held as a YAML string under a single key should be decoded.",
+			args: args{
+				dst: map[string]any{"a": "1"},
+				src: map[string]any{"patch": "- op: add\n  path: /b\n  value: \"2\"\n"},
+				cfg: Config{Engine: EngineJSONPatch},
+			},
+			want: want{out: map[string]any{"a": "1", "b": "2"}},
+		},
+		"JSONPatchFailedOperation": {
+			reason: "A failing JSON patch operation should return an error.",
+			args: args{
+				dst: map[string]any{"a": "1"},
+				src: []any{map[string]any{"op": "remove", "path": "/b"}},
+				cfg: Config{Engine: EngineJSONPatch},
+			},
+			want: want{err: "cannot apply JSON patch: error in remove for path: '/b': unable to remove nonexistent key: b: missing value"},
+		},
+		"StrategicKeyedLists": {
+			reason: "Lists of objects should be merged by key, deleting elements marked for deletion and keys set to null.",
+			args: args{
+				dst: map[string]any{
+					"remove": "me",
+					"containers": []any{
+						map[string]any{"name": "app", "image": "app:v1", "env": "dev"},
+						map[string]any{"name": "sidecar", "image": "sidecar:v1"},
+					},
+					"args": []any{"a"},
+				},
+				src: map[string]any{
+					"remove": nil,
+					"containers": []any{
+						map[string]any{"name": "app", "image": "app:v2"},
+						map[string]any{"name": "sidecar", "$patch": "delete"},
+						map[string]any{"name": "proxy", "image": "proxy:v1"},
+					},
+					"args": []any{"b"},
+				},
+				cfg: Config{Engine: EngineStrategic},
+			},
+			want: want{out: map[string]any{
+				"containers": []any{
+					map[string]any{"name": "app", "image": "app:v2", "env": "dev"},
+					map[string]any{"name": "proxy", "image": "proxy:v1"},
+				},
+				"args": []any{"b"},
+			}},
+		},
+		"StrategicCustomMergeKey": {
+			reason: "Lists of objects should be merged by the configured merge key.",
+			args: args{
+				dst: map[string]any{"ports": []any{map[string]any{"port": int64(80), "protocol": "TCP"}}},
+				src: map[string]any{"ports": []any{map[string]any{"port": int64(80), "name": "http"}}},
+				cfg: Config{Engine: EngineStrategic, MergeKey: "port"},
+			},
+			want: want{out: map[string]any{"ports": []any{map[string]any{"port": int64(80), "protocol": "TCP", "name": "http"}}}},
+		},
+		"UnsupportedEngine": {
+			reason: "Unknown engines should be rejected.",
+			args: args{
+				src: map[string]any{},
+				cfg: Config{Engine: "deep"},
+			},
+			want: want{err: "unsupported merge engine [deep]"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Merge(tc.args.dst, tc.args.src, tc.args.cfg)
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nMerge(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nMerge(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nMerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		src    any
+		want   want
+	}{
+		"Map": {
+			reason: "Objects should be returned as is.",
+			src:    map[string]any{"a": "1"},
+			want:   want{out: map[string]any{"a": "1"}},
+		},
+		"List": {
+			reason: "Lists should be returned as is.",
+			src:    []any{"a"},
+			want:   want{out: []any{"a"}},
+		},
+		"YAMLString": {
+			reason: "Strings holding a YAML object should be decoded, keeping integers as int64.",
+			src:    "a: 1\nb:\n  c: d\n",
+			want:   want{out: map[string]any{"a": int64(1), "b": map[string]any{"c": "d"}}},
+		},
+		"JSONString": {
+			reason: "Strings holding a JSON list should be decoded.",
+			src:    `[{"name": "a"}]`,
+			want:   want{out: []any{map[string]any{"name": "a"}}},
+		},
+		"ScalarString": {
+			reason: "Strings that do not hold an object or a list should be rejected.",
+			src:    "plain",
+			want:   want{err: "unsupported source data: string does not hold an object or a list"},
+		},
+		"Scalar": {
+			reason: "Scalars should be rejected with the unsupported type.",
+			src:    int64(1),
+			want:   want{err: "unsupported source data of type int64: expected an object, a list or a string holding a YAML/JSON document"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := ParseSource(tc.src)
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nParseSource(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nParseSource(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nParseSource(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package merger
+
+import (
+	"reflect"
+	"strings"
+
+	"dario.cat/mergo"
+	"github.com/pkg/errors"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// mergeMergo merges src into dst using mergo. Lists found at the Config.ListMergeKeys paths are merged element by
+// element, matching elements by key, instead of being appended or replaced. Paths are field paths relative to the
+// merged document, list elements do not add a path segment (e.g. `spec.items.ports`).
+func mergeMergo(dst, src map[string]any, cfg Config, prefix string) (map[string]any, error) {
+	pruned, _, err := mergeKeyedLists(dst, src, cfg, prefix)
+	if err != nil {
+		return nil, err
+	}
+	// Merging nothing leaves dst untouched, whereas mergo replaces dst with the empty source when overwriting empty
+	// values.
+	if len(pruned) == 0 {
+		return dst, nil
+	}
+	if err := mergo.Merge(&dst, pruned, cfg.Options.Mergo()...); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// mergeKeyedLists merges the keyed lists of src into dst and returns a copy of src without them.
+// The returned boolean reports whether any list was pruned from src.
+func mergeKeyedLists(dst, src map[string]any, cfg Config, prefix string) (map[string]any, bool, error) {
+	if len(cfg.ListMergeKeys) == 0 {
+		return src, false, nil
+	}
+
+	var pruned map[string]any
+	prune := func(k string, v any, remove bool) {
+		if pruned == nil {
+			pruned = make(map[string]any, len(src))
+			for sk, sv := range src {
+				pruned[sk] = sv
+			}
+		}
+		if remove {
+			delete(pruned, k)
+			return
+		}
+		pruned[k] = v
+	}
+
+	for k, sv := range src {
+		dv, ok := dst[k]
+		if !ok {
+			continue
+		}
+		path := joinPath(prefix, k)
+
+		if key, ok := cfg.ListMergeKeys[path]; ok {
+			dl, dok := dv.([]any)
+			sl, sok := sv.([]any)
+			if dok && sok && isKeyedList(dl, key) && isKeyedList(sl, key) {
+				merged, err := mergeKeyedList(dl, sl, key, func(de, se map[string]any) (map[string]any, error) {
+					return mergeMergo(de, se, cfg, path)
+				})
+				if err != nil {
+					return nil, false, err
+				}
+				dst[k] = merged
+				prune(k, nil, true)
+				continue
+			}
+		}
+
+		dm, dok := dv.(map[string]any)
+		sm, sok := sv.(map[string]any)
+		if !dok || !sok || !cfg.hasListMergeKeysUnder(path) {
+			continue
+		}
+		sub, changed, err := mergeKeyedLists(dm, sm, cfg, path)
+		if err != nil {
+			return nil, false, err
+		}
+		if changed {
+			prune(k, sub, len(sub) == 0)
+		}
+	}
+
+	if pruned == nil {
+		return src, false, nil
+	}
+	return pruned, true, nil
+}
+
+func (c Config) hasListMergeKeysUnder(path string) bool {
+	for p := range c.ListMergeKeys {
+		if strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(prefix, key string) string {
+	return maps.JoinPath(prefix, key)
+}
+
+// ListMode determines how sources holding lists are merged.
+type ListMode string
+
+const (
+	// ListConcatenate appends the elements of later sources.
+	ListConcatenate ListMode = "concatenate"
+	// ListUnique appends the elements of later sources that are not already merged.
+	ListUnique ListMode = "unique"
+	// ListKeyed matches objects by MergeKey, merging matching objects and appending new ones.
+	ListKeyed ListMode = "keyed"
+)
+
+// MergeList merges the source list into the destination list and returns the result.
+// Objects matched by the keyed mode are merged following the engine and options of the configuration.
+func MergeList(dst, src []any, mode ListMode, cfg Config) ([]any, error) {
+	switch mode {
+	case "", ListConcatenate:
+		return append(append(make([]any, 0, len(dst)+len(src)), dst...), src...), nil
+	case ListUnique:
+		out := make([]any, 0, len(dst)+len(src))
+		for _, e := range append(append(make([]any, 0, len(dst)+len(src)), dst...), src...) {
+			if !containsValue(out, e) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	case ListKeyed:
+		key := cfg.MergeKey
+		if key == "" {
+			key = DefaultMergeKey
+		}
+		if !isKeyedList(dst, key) || !isKeyedList(src, key) {
+			return nil, errors.Errorf("%s list merge requires objects holding the merge key [%s]", ListKeyed, key)
+		}
+		elementCfg := Config{Engine: cfg.Engine, Options: cfg.Options, MergeKey: cfg.MergeKey}
+		return mergeKeyedList(dst, src, key, func(d, s map[string]any) (map[string]any, error) {
+			return Merge(d, s, elementCfg)
+		})
+	default:
+		return nil, errors.Errorf("unsupported list merge mode [%s]", mode)
+	}
+}
+
+func containsValue(l []any, v any) bool {
+	for _, e := range l {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
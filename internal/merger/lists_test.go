@@ -0,0 +1,180 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeListMergeKeys(t *testing.T) {
+	type args struct {
+		dst  map[string]any
+		src  map[string]any
+		opts map[string]bool
+		keys map[string]string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   map[string]any
+	}{
+		"KeyedList": {
+			reason: "Elements of a keyed list should be matched by key and deep merged, new elements being appended.",
+			args: args{
+				dst: map[string]any{"spec": map[string]any{"items": []any{
+					map[string]any{"name": "a", "value": "1", "keep": "yes"},
+					map[string]any{"name": "b", "value": "2"},
+				}}},
+				src: map[string]any{"spec": map[string]any{"items": []any{
+					map[string]any{"name": "b", "value": "3"},
+					map[string]any{"name": "c", "value": "4"},
+				}}},
+				opts: map[string]bool{"override": true},
+				keys: map[string]string{"spec.items": "name"},
+			},
+			want: map[string]any{"spec": map[string]any{"items": []any{
+				map[string]any{"name": "a", "value": "1", "keep": "yes"},
+				map[string]any{"name": "b", "value": "3"},
+				map[string]any{"name": "c", "value": "4"},
+			}}},
+		},
+		"NestedKeyedLists": {
+			reason: "Keyed lists nested in keyed list elements should be merged by their own key.",
+			args: args{
+				dst: map[string]any{"spec": map[string]any{"items": []any{
+					map[string]any{"name": "app", "ports": []any{
+						map[string]any{"port": int64(80), "protocol": "TCP"},
+					}},
+				}}},
+				src: map[string]any{"spec": map[string]any{"items": []any{
+					map[string]any{"name": "app", "ports": []any{
+						map[string]any{"port": int64(80), "name": "http"},
+						map[string]any{"port": int64(443), "name": "https"},
+					}},
+				}}},
+				keys: map[string]string{"spec.items": "name", "spec.items.ports": "port"},
+			},
+			want: map[string]any{"spec": map[string]any{"items": []any{
+				map[string]any{"name": "app", "ports": []any{
+					map[string]any{"port": int64(80), "protocol": "TCP", "name": "http"},
+					map[string]any{"port": int64(443), "name": "https"},
+				}},
+			}}},
+		},
+		"UnkeyedListsUseOptions": {
+			reason: "Lists that are not configured should follow the mergo options.",
+			args: args{
+				dst:  map[string]any{"tags": []any{"a"}, "items": []any{map[string]any{"name": "a"}}},
+				src:  map[string]any{"tags": []any{"b"}, "items": []any{map[string]any{"name": "b"}}},
+				opts: map[string]bool{"appendSlice": true},
+				keys: map[string]string{"items": "name"},
+			},
+			want: map[string]any{"tags": []any{"a", "b"}, "items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+		},
+		"ElementsWithoutKey": {
+			reason: "Lists holding elements without the key should fall back to the mergo options.",
+			args: args{
+				dst:  map[string]any{"items": []any{map[string]any{"name": "a"}}},
+				src:  map[string]any{"items": []any{map[string]any{"id": "b"}}},
+				opts: map[string]bool{"override": true},
+				keys: map[string]string{"items": "name"},
+			},
+			want: map[string]any{"items": []any{map[string]any{"id": "b"}}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := WithSourceOpts(Options{}, tc.args.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Merge(tc.args.dst, tc.args.src, Config{Options: opts, ListMergeKeys: tc.args.keys})
+			if err != nil {
+				t.Fatalf("%s\nMerge(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nMerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeList(t *testing.T) {
+	type args struct {
+		dst  []any
+		src  []any
+		mode ListMode
+		opts map[string]bool
+	}
+	type want struct {
+		out []any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Concatenate": {
+			reason: "The elements of the source should be appended.",
+			args: args{
+				dst: []any{"a", "b"},
+				src: []any{"b", "c"},
+			},
+			want: want{out: []any{"a", "b", "b", "c"}},
+		},
+		"Unique": {
+			reason: "Only the elements that are not merged yet should be appended.",
+			args: args{
+				dst:  []any{"a", map[string]any{"b": "1"}},
+				src:  []any{map[string]any{"b": "1"}, "c", "c"},
+				mode: ListUnique,
+			},
+			want: want{out: []any{"a", map[string]any{"b": "1"}, "c"}},
+		},
+		"Keyed": {
+			reason: "Objects should be matched by the merge key and merged following the options.",
+			args: args{
+				dst:  []any{map[string]any{"name": "a", "value": "1", "keep": "yes"}},
+				src:  []any{map[string]any{"name": "a", "value": "2"}, map[string]any{"name": "b"}},
+				mode: ListKeyed,
+				opts: map[string]bool{"override": true},
+			},
+			want: want{out: []any{map[string]any{"name": "a", "value": "2", "keep": "yes"}, map[string]any{"name": "b"}}},
+		},
+		"KeyedWithoutKey": {
+			reason: "Keyed merges should reject elements without the merge key.",
+			args: args{
+				dst:  []any{map[string]any{"name": "a"}},
+				src:  []any{"b"},
+				mode: ListKeyed,
+			},
+			want: want{err: "keyed list merge requires objects holding the merge key [name]"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := WithSourceOpts(Options{}, tc.args.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := MergeList(tc.args.dst, tc.args.src, tc.args.mode, Config{Options: opts})
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nMergeList(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nMergeList(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nMergeList(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
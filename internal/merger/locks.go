@@ -0,0 +1,127 @@
+package merger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// Violation is an attempt by a source to change a locked value.
+type Violation struct {
+	Path   string
+	Owner  string
+	Source string
+}
+
+// Error returns a description of the violation.
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s attempted to override locked key [%s] set by %s", v.Source, v.Path, v.Owner)
+}
+
+type lock struct {
+	segments []string
+	value    any
+	owner    string
+}
+
+// Locks tracks merged values that later sources cannot change.
+type Locks struct {
+	locks map[string]lock
+}
+
+// NewLocks creates an empty set of locks.
+func NewLocks() *Locks {
+	return &Locks{locks: make(map[string]lock)}
+}
+
+// Add locks the values currently found at the field paths of the merged document on behalf of the owner and returns
+// the paths matching no value, which are not locked. Keys holding periods are written between brackets, e.g.
+// `[db.host]`. Paths that are already locked keep their original owner.
+func (l *Locks) Add(doc map[string]any, paths []string, owner string) ([]string, error) {
+	var unmatched []string
+	for _, p := range paths {
+		segments, err := maps.SplitPath(p)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid locked key")
+		}
+		path := joinSegments(segments)
+		if _, ok := l.locks[path]; ok {
+			continue
+		}
+		v, ok := lookupPath(doc, segments)
+		if !ok {
+			unmatched = append(unmatched, p)
+			continue
+		}
+		l.locks[path] = lock{segments: segments, value: deepCopy(v), owner: owner}
+	}
+	return unmatched, nil
+}
+
+// Enforce restores the locked values of the merged document that were changed by the source and returns the
+// violations in path order.
+func (l *Locks) Enforce(doc map[string]any, source string) []Violation {
+	paths := make([]string, 0, len(l.locks))
+	for p := range l.locks {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var out []Violation
+	for _, p := range paths {
+		lk := l.locks[p]
+		if v, ok := lookupPath(doc, lk.segments); ok && reflect.DeepEqual(v, lk.value) {
+			continue
+		}
+		forcePath(doc, lk.segments, deepCopy(lk.value))
+		out = append(out, Violation{Path: p, Owner: lk.owner, Source: source})
+	}
+	return out
+}
+
+// forcePath sets the value at the path, replacing any value along the path that is not an object.
+func forcePath(m map[string]any, segments []string, value any) {
+	current := m
+	for _, s := range segments[:len(segments)-1] {
+		next, ok := current[s].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[s] = next
+		}
+		current = next
+	}
+	current[segments[len(segments)-1]] = value
+}
+
+// joinSegments renders the keys of a path as a field path.
+func joinSegments(segments []string) string {
+	var path string
+	for _, s := range segments {
+		path = joinPath(path, s)
+	}
+	return path
+}
+
+// deepCopy copies the maps and lists of an unstructured value.
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = deepCopy(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,115 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocks(t *testing.T) {
+	type want struct {
+		unmatched  []string
+		err        string
+		doc        map[string]any
+		violations []Violation
+	}
+
+	cases := map[string]struct {
+		reason string
+		locked map[string]any
+		paths  []string
+		merged map[string]any
+		want   want
+	}{
+		"Unchanged": {
+			reason: "Locked values left untouched should not be reported.",
+			locked: map[string]any{"security": map[string]any{"tls": "on"}, "a": "1"},
+			paths:  []string{"security.tls"},
+			merged: map[string]any{"security": map[string]any{"tls": "on"}, "a": "2"},
+			want: want{
+				doc: map[string]any{"security": map[string]any{"tls": "on"}, "a": "2"},
+			},
+		},
+		"ChangedAndRemoved": {
+			reason: "Changed and removed locked values should be restored and reported.",
+			locked: map[string]any{"security": map[string]any{"tls": "on", "level": "high"}, "tags": []any{"a"}},
+			paths:  []string{"security", "tags", "missing"},
+			merged: map[string]any{"security": "none"},
+			want: want{
+				unmatched: []string{"missing"},
+				doc:       map[string]any{"security": map[string]any{"tls": "on", "level": "high"}, "tags": []any{"a"}},
+				violations: []Violation{
+					{Path: "security", Owner: "ConfigMap/baseline", Source: "ConfigMap/team"},
+					{Path: "tags", Owner: "ConfigMap/baseline", Source: "ConfigMap/team"},
+				},
+			},
+		},
+		"NestedPathReplacedParent": {
+			reason: "A locked nested value should be restored when its parent is replaced.",
+			locked: map[string]any{"security": map[string]any{"tls": "on"}},
+			paths:  []string{"security.tls"},
+			merged: map[string]any{"security": "off"},
+			want: want{
+				doc: map[string]any{"security": map[string]any{"tls": "on"}},
+				violations: []Violation{
+					{Path: "security.tls", Owner: "ConfigMap/baseline", Source: "ConfigMap/team"},
+				},
+			},
+		},
+		"KeysWithPeriods": {
+			reason: "Keys holding periods should be locked when written between brackets and reported when unmatched.",
+			locked: map[string]any{"db.host": "primary", "app": map[string]any{"tls.enabled": "true"}},
+			paths:  []string{"[db.host]", "app[tls.enabled]", "db.host"},
+			merged: map[string]any{"db.host": "replica", "app": map[string]any{"tls.enabled": "false"}},
+			want: want{
+				unmatched: []string{"db.host"},
+				doc:       map[string]any{"db.host": "primary", "app": map[string]any{"tls.enabled": "true"}},
+				violations: []Violation{
+					{Path: "[db.host]", Owner: "ConfigMap/baseline", Source: "ConfigMap/team"},
+					{Path: "app[tls.enabled]", Owner: "ConfigMap/baseline", Source: "ConfigMap/team"},
+				},
+			},
+		},
+		"InvalidPath": {
+			reason: "An invalid path should be an error.",
+			locked: map[string]any{},
+			paths:  []string{"db[host"},
+			merged: map[string]any{},
+			want: want{
+				err: "invalid locked key: invalid path [db[host]: unterminated '[' at position 2",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			locks := NewLocks()
+			unmatched, err := locks.Add(tc.locked, tc.paths, "ConfigMap/baseline")
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nAdd(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nAdd(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.unmatched, unmatched); diff != "" {
+				t.Errorf("%s\nAdd(...): -want unmatched, +got unmatched:\n%s", tc.reason, diff)
+			}
+
+			// Mutating the merged document in place must not alter the locked values.
+			if m, ok := tc.locked["security"].(map[string]any); ok {
+				m["tls"] = "mutated"
+			}
+
+			violations := locks.Enforce(tc.merged, "ConfigMap/team")
+			if diff := cmp.Diff(tc.want.violations, violations); diff != "" {
+				t.Errorf("%s\nEnforce(...): -want violations, +got violations:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.doc, tc.merged); diff != "" {
+				t.Errorf("%s\nEnforce(...): -want doc, +got doc:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -9,19 +9,47 @@ import (
 	"github.com/crossplane/function-sdk-go/resource"
 )
 
-// ParseMergoOpts parses the options from the XR and returns a map of mergo options
-func ParseMergoOpts(xr *resource.Composite) (out map[string]func(*mergo.Config), err error) {
-	out = make(map[string]func(*mergo.Config))
-	if xr == nil {
-		return out, nil
+// Option is a named mergo option.
+type Option struct {
+	Name  string
+	Apply func(*mergo.Config)
+}
+
+// Options is a list of mergo options in application order.
+type Options []Option
+
+// optsOrder lists the supported mergo options in the order they are applied.
+var optsOrder = Options{
+	{Name: "override", Apply: mergo.WithOverride},
+	{Name: "typeCheck", Apply: mergo.WithTypeCheck},
+	{Name: "overwriteEmptyValue", Apply: mergo.WithOverwriteWithEmptyValue},
+	{Name: "overrideEmptySlice", Apply: mergo.WithOverrideEmptySlice},
+	{Name: "appendSlice", Apply: mergo.WithAppendSlice},
+	{Name: "sliceDeepCopy", Apply: mergo.WithSliceDeepCopy},
+}
+
+// Names returns the names of the options in application order.
+func (o Options) Names() []string {
+	out := make([]string, 0, len(o))
+	for _, opt := range o {
+		out = append(out, opt.Name)
 	}
-	optsMap := map[string]func(*mergo.Config){
-		"override":            mergo.WithOverride,
-		"appendSlice":         mergo.WithAppendSlice,
-		"sliceDeepCopy":       mergo.WithSliceDeepCopy,
-		"overwriteEmptyValue": mergo.WithOverwriteWithEmptyValue,
-		"overrideEmptySlice":  mergo.WithOverrideEmptySlice,
-		"typeCheck":           mergo.WithTypeCheck,
+	return out
+}
+
+// Mergo returns the mergo options in application order.
+func (o Options) Mergo() []func(*mergo.Config) {
+	out := make([]func(*mergo.Config), 0, len(o))
+	for _, opt := range o {
+		out = append(out, opt.Apply)
+	}
+	return out
+}
+
+// ParseMergoOpts parses the options from the XR and returns the enabled mergo options in application order.
+func ParseMergoOpts(xr *resource.Composite) (Options, error) {
+	if xr == nil {
+		return Options{}, nil
 	}
 
 	type xrSpec struct {
@@ -31,17 +59,40 @@ func ParseMergoOpts(xr *resource.Composite) (out map[string]func(*mergo.Config),
 	}
 
 	var xrConfig xrSpec
-	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(xr.Resource.Object, &xrConfig); err != nil {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(xr.Resource.Object, &xrConfig); err != nil {
 		return nil, errors.Wrap(err, "cannot convert XR to struct")
 	}
+	return WithSourceOpts(Options{}, xrConfig.Spec.Options)
+}
 
-	for opt, enabled := range xrConfig.Spec.Options {
-		if !enabled {
-			continue
+// WithSourceOpts returns the default mergo options overridden by the options declared on a source.
+// Options enabled by the source are added to the defaults while options disabled by the source are removed.
+func WithSourceOpts(defaults Options, opts map[string]bool) (Options, error) {
+	enabled := make(map[string]bool, len(defaults)+len(opts))
+	for _, opt := range defaults {
+		enabled[opt.Name] = true
+	}
+	for name, on := range opts {
+		if !supported(name) {
+			return nil, errors.Errorf("unknown merge option [%s]", name)
 		}
-		if o, ok := optsMap[opt]; ok {
-			out[opt] = o
+		enabled[name] = on
+	}
+
+	out := make(Options, 0, len(enabled))
+	for _, opt := range optsOrder {
+		if enabled[opt.Name] {
+			out = append(out, opt)
 		}
 	}
 	return out, nil
 }
+
+func supported(name string) bool {
+	for _, opt := range optsOrder {
+		if opt.Name == name {
+			return true
+		}
+	}
+	return false
+}
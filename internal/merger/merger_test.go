@@ -0,0 +1,148 @@
+package merger
+
+import (
+	"testing"
+
+	"dario.cat/mergo"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func newXR(options map[string]any) *resource.Composite {
+	return &resource.Composite{
+		Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"options": options},
+		}}},
+	}
+}
+
+func TestParseMergoOpts(t *testing.T) {
+	type want struct {
+		names []string
+		err   string
+	}
+
+	cases := map[string]struct {
+		reason string
+		xr     *resource.Composite
+		want   want
+	}{
+		"NoXR": {
+			reason: "No options should be returned without an XR.",
+			want:   want{names: []string{}},
+		},
+		"OrderedOptions": {
+			reason: "Enabled options should be returned in application order regardless of declaration order.",
+			xr: newXR(map[string]any{
+				"sliceDeepCopy":       true,
+				"appendSlice":         true,
+				"overwriteEmptyValue": true,
+				"override":            true,
+				"typeCheck":           false,
+			}),
+			want: want{names: []string{"override", "overwriteEmptyValue", "appendSlice", "sliceDeepCopy"}},
+		},
+		"UnknownOption": {
+			reason: "Unknown options should be rejected.",
+			xr:     newXR(map[string]any{"override": true, "overide": true}),
+			want:   want{err: "unknown merge option [overide]"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := ParseMergoOpts(tc.xr)
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nParseMergoOpts(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nParseMergoOpts(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.names, opts.Names()); diff != "" {
+				t.Errorf("%s\nParseMergoOpts(...): -want names, +got names:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWithSourceOpts(t *testing.T) {
+	defaults, err := ParseMergoOpts(newXR(map[string]any{"override": true, "appendSlice": true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		opts   map[string]bool
+		want   []string
+		err    string
+	}{
+		"NoSourceOptions": {
+			reason: "The defaults should be returned when the source declares no options.",
+			want:   []string{"override", "appendSlice"},
+		},
+		"DisableAndEnable": {
+			reason: "Source options should add to and remove from the defaults.",
+			opts:   map[string]bool{"override": false, "typeCheck": true},
+			want:   []string{"typeCheck", "appendSlice"},
+		},
+		"UnknownOption": {
+			reason: "Unknown source options should be rejected.",
+			opts:   map[string]bool{"deepMerge": true},
+			err:    "unknown merge option [deepMerge]",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := WithSourceOpts(defaults, tc.opts)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("%s\nWithSourceOpts(...): want error %q, got %v", tc.reason, tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nWithSourceOpts(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, opts.Names()); diff != "" {
+				t.Errorf("%s\nWithSourceOpts(...): -want names, +got names:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeIsReproducible(t *testing.T) {
+	xr := newXR(map[string]any{
+		"override":            true,
+		"overwriteEmptyValue": true,
+		"appendSlice":         true,
+		"sliceDeepCopy":       true,
+	})
+
+	merge := func() map[string]any {
+		opts, err := ParseMergoOpts(xr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := map[string]any{"a": "1", "b": "2", "list": []any{"x"}, "nested": map[string]any{"c": "3"}}
+		src := map[string]any{"a": "", "list": []any{"y"}, "nested": map[string]any{"c": "4", "d": "5"}}
+		if err := mergo.Merge(&dst, src, opts.Mergo()...); err != nil {
+			t.Fatal(err)
+		}
+		return dst
+	}
+
+	want := merge()
+	for i := 0; i < 100; i++ {
+		if diff := cmp.Diff(want, merge()); diff != "" {
+			t.Fatalf("run %d: merge output is not reproducible: -want, +got:\n%s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package merger
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// Policy determines how the value found at a path is merged.
+type Policy string
+
+const (
+	// PolicyOverride replaces the merged value with the value of the later source.
+	PolicyOverride Policy = "override"
+	// PolicyKeepFirst keeps the value of the first source setting it.
+	PolicyKeepFirst Policy = "keepFirst"
+	// PolicyAppend concatenates lists. Values that are not lists are overridden.
+	PolicyAppend Policy = "append"
+)
+
+// applyRules merges the values found at the rule paths of src into dst and returns a copy of src without them.
+// Rule paths are field paths relative to the merged document, keys holding periods being written between brackets,
+// e.g. `[app.properties]`. Values are looked up before any rule is applied and deeper paths are applied last, so that
+// the most specific rule wins.
+func applyRules(dst, src map[string]any, rules map[string]Policy) (map[string]any, error) {
+	if len(rules) == 0 {
+		return src, nil
+	}
+
+	paths := make([]string, 0, len(rules))
+	segments := make(map[string][]string, len(rules))
+	for p := range rules {
+		s, err := maps.SplitPath(p)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid merge rule path")
+		}
+		paths = append(paths, p)
+		segments[p] = s
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := len(segments[paths[i]]), len(segments[paths[j]])
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+
+	type values struct {
+		src, dst     any
+		inSrc, inDst bool
+	}
+	snapshot := make(map[string]values, len(paths))
+	for _, p := range paths {
+		var v values
+		v.src, v.inSrc = lookupPath(src, segments[p])
+		v.dst, v.inDst = lookupPath(dst, segments[p])
+		snapshot[p] = v
+	}
+
+	for _, p := range paths {
+		v := snapshot[p]
+		if !v.inSrc {
+			continue
+		}
+
+		value := v.src
+		switch rules[p] {
+		case PolicyOverride:
+		case PolicyKeepFirst:
+			if v.inDst {
+				value = v.dst
+			}
+		case PolicyAppend:
+			dl, dok := v.dst.([]any)
+			sl, sok := v.src.([]any)
+			if v.inDst && dok && sok {
+				value = append(append(make([]any, 0, len(dl)+len(sl)), dl...), sl...)
+			}
+		default:
+			return nil, errors.Errorf("unsupported merge policy [%s] for path [%s]", rules[p], p)
+		}
+
+		if err := setPath(dst, segments[p], value); err != nil {
+			return nil, errors.Wrapf(err, "cannot apply merge policy [%s] to path [%s]", rules[p], p)
+		}
+	}
+
+	for _, p := range paths {
+		src = withoutPath(src, segments[p])
+	}
+	return src, nil
+}
+
+func lookupPath(m map[string]any, segments []string) (any, bool) {
+	var current any = m
+	for _, s := range segments {
+		cm, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = cm[s]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setPath(m map[string]any, segments []string, value any) error {
+	current := m
+	for i, s := range segments[:len(segments)-1] {
+		next, ok := current[s]
+		if !ok {
+			created := make(map[string]any)
+			current[s] = created
+			current = created
+			continue
+		}
+		nm, ok := next.(map[string]any)
+		if !ok {
+			return errors.Errorf("%s is not an object", joinSegments(segments[:i+1]))
+		}
+		current = nm
+	}
+	current[segments[len(segments)-1]] = value
+	return nil
+}
+
+// withoutPath returns a copy of m without the value at the path. Maps along the path are copied so that m is
+// left untouched and maps left empty by the removal are removed as well, so they cannot override merged values.
+func withoutPath(m map[string]any, segments []string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	if len(segments) == 1 {
+		delete(out, segments[0])
+		return out
+	}
+	if nm, ok := out[segments[0]].(map[string]any); ok {
+		out[segments[0]] = withoutPath(nm, segments[1:])
+		if len(out[segments[0]].(map[string]any)) == 0 {
+			delete(out, segments[0])
+		}
+	}
+	return out
+}
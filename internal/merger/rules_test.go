@@ -0,0 +1,144 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeRules(t *testing.T) {
+	type args struct {
+		dst    map[string]any
+		src    map[string]any
+		engine Engine
+		opts   map[string]bool
+		rules  map[string]Policy
+	}
+	type want struct {
+		out map[string]any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"PathPolicies": {
+			reason: "Each path should be merged following its policy while other paths follow the options.",
+			args: args{
+				dst: map[string]any{"data": map[string]any{
+					"replicas": int64(1),
+					"tags":     []any{"a"},
+					"secrets":  map[string]any{"tls": "first"},
+					"other":    "dst",
+				}},
+				src: map[string]any{"data": map[string]any{
+					"replicas": int64(3),
+					"tags":     []any{"b"},
+					"secrets":  map[string]any{"tls": "second", "extra": "x"},
+					"other":    "src",
+				}},
+				rules: map[string]Policy{
+					"data.replicas": PolicyOverride,
+					"data.tags":     PolicyAppend,
+					"data.secrets":  PolicyKeepFirst,
+				},
+			},
+			want: want{out: map[string]any{"data": map[string]any{
+				"replicas": int64(3),
+				"tags":     []any{"a", "b"},
+				"secrets":  map[string]any{"tls": "first"},
+				"other":    "dst",
+			}}},
+		},
+		"KeepFirstUnsetValue": {
+			reason: "A keepFirst path that is not yet set should take the value of the source.",
+			args: args{
+				dst:   map[string]any{"a": "1"},
+				src:   map[string]any{"security": map[string]any{"tls": "on"}},
+				rules: map[string]Policy{"security.tls": PolicyKeepFirst},
+			},
+			want: want{out: map[string]any{"a": "1", "security": map[string]any{"tls": "on"}}},
+		},
+		"MostSpecificRuleWins": {
+			reason: "Deeper rule paths should take precedence over their parents.",
+			args: args{
+				dst:   map[string]any{"security": map[string]any{"tls": "on", "level": "low"}},
+				src:   map[string]any{"security": map[string]any{"tls": "off", "level": "high"}},
+				opts:  map[string]bool{"override": true, "overwriteEmptyValue": true},
+				rules: map[string]Policy{"security": PolicyOverride, "security.tls": PolicyKeepFirst},
+			},
+			want: want{out: map[string]any{"security": map[string]any{"tls": "on", "level": "high"}}},
+		},
+		"KeysWithPeriods": {
+			reason: "A rule path should address a key holding periods when written between brackets.",
+			args: args{
+				dst:   map[string]any{"app.properties": "first", "app": map[string]any{"properties": "nested"}},
+				src:   map[string]any{"app.properties": "second", "app": map[string]any{"properties": "override"}},
+				opts:  map[string]bool{"override": true},
+				rules: map[string]Policy{"[app.properties]": PolicyKeepFirst},
+			},
+			want: want{out: map[string]any{"app.properties": "first", "app": map[string]any{"properties": "override"}}},
+		},
+		"StrategicEngine": {
+			reason: "Rules should apply to the strategic engine.",
+			args: args{
+				dst:    map[string]any{"a": "1", "b": "1"},
+				src:    map[string]any{"a": "2", "b": "2"},
+				engine: EngineStrategic,
+				rules:  map[string]Policy{"a": PolicyKeepFirst},
+			},
+			want: want{out: map[string]any{"a": "1", "b": "2"}},
+		},
+		"NotAnObject": {
+			reason: "A rule path crossing a value that is not an object should return an error.",
+			args: args{
+				dst:   map[string]any{"a": "1"},
+				src:   map[string]any{"a": map[string]any{"b": "2"}},
+				rules: map[string]Policy{"a.b": PolicyOverride},
+			},
+			want: want{err: "cannot apply merge policy [override] to path [a.b]: a is not an object"},
+		},
+		"InvalidPath": {
+			reason: "A rule path that cannot be parsed should return an error.",
+			args: args{
+				dst:   map[string]any{"a": "1"},
+				src:   map[string]any{"a": "2"},
+				rules: map[string]Policy{"a[b": PolicyOverride},
+			},
+			want: want{err: "invalid merge rule path: invalid path [a[b]: unterminated '[' at position 1"},
+		},
+		"UnsupportedPolicy": {
+			reason: "Unknown policies should be rejected.",
+			args: args{
+				dst:   map[string]any{"a": "1"},
+				src:   map[string]any{"a": "2"},
+				rules: map[string]Policy{"a": "keepLast"},
+			},
+			want: want{err: "unsupported merge policy [keepLast] for path [a]"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := WithSourceOpts(Options{}, tc.args.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Merge(tc.args.dst, tc.args.src, Config{Engine: tc.args.engine, Options: opts, Rules: tc.args.rules})
+			if tc.want.err != "" {
+				if err == nil || err.Error() != tc.want.err {
+					t.Errorf("%s\nMerge(...): want error %q, got %v", tc.reason, tc.want.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s\nMerge(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.out, got); diff != "" {
+				t.Errorf("%s\nMerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package merger
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// patchDirective is the strategic merge patch directive key. A list element holding `$patch: delete`
+// removes the element matching its merge key.
+const patchDirective = "$patch"
+
+// mergeStrategic deep merges src into dst. Null values delete keys, lists of objects holding the merge key
+// are merged element by element and any other value is replaced.
+func mergeStrategic(dst, src map[string]any, key string) map[string]any {
+	out := make(map[string]any, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, sv := range src {
+		if sv == nil {
+			delete(out, k)
+			continue
+		}
+		dv, ok := out[k]
+		if !ok {
+			out[k] = sv
+			continue
+		}
+		out[k] = mergeStrategicValue(dv, sv, key)
+	}
+	return out
+}
+
+func mergeStrategicValue(dv, sv any, key string) any {
+	switch s := sv.(type) {
+	case map[string]any:
+		if d, ok := dv.(map[string]any); ok {
+			return mergeStrategic(d, s, key)
+		}
+	case []any:
+		if d, ok := dv.([]any); ok && isKeyedList(d, key) && isKeyedList(s, key) {
+			merged, _ := mergeKeyedList(d, s, key, func(de, se map[string]any) (map[string]any, error) {
+				return mergeStrategic(de, se, key), nil
+			})
+			return merged
+		}
+	}
+	return sv
+}
+
+// isKeyedList reports whether every element of the list is an object holding the key.
+func isKeyedList(l []any, key string) bool {
+	for _, e := range l {
+		m, ok := e.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeKeyedList merges the src list into the dst list matching elements by key. Matching elements are merged
+// with the supplied function, new elements are appended in order and elements holding `$patch: delete` are removed.
+func mergeKeyedList(dst, src []any, key string, merge func(dst, src map[string]any) (map[string]any, error)) ([]any, error) {
+	out := make([]any, 0, len(dst)+len(src))
+	index := make(map[string]int, len(dst))
+	for _, e := range dst {
+		m := e.(map[string]any)
+		index[keyOf(m, key)] = len(out)
+		out = append(out, m)
+	}
+
+	deleted := make(map[int]bool)
+	for _, e := range src {
+		m := e.(map[string]any)
+		id := keyOf(m, key)
+		i, exists := index[id]
+		if m[patchDirective] == "delete" {
+			if exists {
+				deleted[i] = true
+				delete(index, id)
+			}
+			continue
+		}
+		if !exists {
+			index[id] = len(out)
+			out = append(out, m)
+			continue
+		}
+		merged, err := merge(out[i].(map[string]any), m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot merge list element [%s=%s]", key, id)
+		}
+		out[i] = merged
+	}
+
+	if len(deleted) == 0 {
+		return out, nil
+	}
+	kept := make([]any, 0, len(out)-len(deleted))
+	for i, e := range out {
+		if !deleted[i] {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+func keyOf(m map[string]any, key string) string {
+	return fmt.Sprint(m[key])
+}
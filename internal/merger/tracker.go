@@ -0,0 +1,103 @@
+package merger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Conflict is a scalar leaf set to different values by two sources.
+type Conflict struct {
+	Path   string
+	Owner  string
+	Source string
+}
+
+// Error returns a description of the conflict.
+func (c Conflict) Error() string {
+	return fmt.Sprintf("conflicting values for key [%s] set by %s and %s", c.Path, c.Owner, c.Source)
+}
+
+// Tracker records the source owning each leaf of the merged document.
+type Tracker struct {
+	owners map[string]string
+}
+
+// NewTracker creates an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{owners: make(map[string]string)}
+}
+
+// Leaves flattens a document into its leaf values keyed by dot separated path. Lists are considered leaves.
+// Values are copied so the leaves are not altered by merges mutating the document in place.
+func Leaves(doc map[string]any) map[string]any {
+	out := make(map[string]any)
+	flatten(doc, "", out)
+	return out
+}
+
+func flatten(m map[string]any, prefix string, out map[string]any) {
+	for k, v := range m {
+		path := joinPath(prefix, k)
+		if nm, ok := v.(map[string]any); ok && len(nm) > 0 {
+			flatten(nm, path, out)
+			continue
+		}
+		out[path] = deepCopy(v)
+	}
+}
+
+// Conflicts returns the scalar leaves of the source conflicting with the leaves of the merged document, in path
+// order. Sources that are not maps and null values, which delete keys, never conflict.
+func (t *Tracker) Conflicts(leaves map[string]any, src any, source string) []Conflict {
+	srcMap, ok := src.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var out []Conflict
+	for path, sv := range Leaves(srcMap) {
+		dv, ok := leaves[path]
+		if !ok || !isScalar(sv) || !isScalar(dv) || reflect.DeepEqual(sv, dv) {
+			continue
+		}
+		out = append(out, Conflict{Path: path, Owner: t.owners[path], Source: source})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Record assigns the leaves added or changed by merging the source to the source. The leaves are the leaves of the
+// merged document before the source was merged.
+func (t *Tracker) Record(leaves, merged map[string]any, source string) {
+	after := Leaves(merged)
+	for path := range t.owners {
+		if _, ok := after[path]; !ok {
+			delete(t.owners, path)
+		}
+	}
+	for path, v := range after {
+		if before, ok := leaves[path]; ok && reflect.DeepEqual(before, v) {
+			continue
+		}
+		t.owners[path] = source
+	}
+}
+
+// Owners returns the source owning each leaf of the merged document keyed by dot separated path.
+func (t *Tracker) Owners() map[string]string {
+	out := make(map[string]string, len(t.owners))
+	for path, owner := range t.owners {
+		out[path] = owner
+	}
+	return out
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case nil, map[string]any, []any:
+		return false
+	default:
+		return true
+	}
+}
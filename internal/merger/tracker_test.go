@@ -0,0 +1,97 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker(t *testing.T) {
+	type want struct {
+		conflicts []Conflict
+		owners    map[string]string
+	}
+
+	cases := map[string]struct {
+		reason string
+		first  map[string]any
+		second any
+		opts   map[string]bool
+		want   want
+	}{
+		"ConflictingScalars": {
+			reason: "Scalar leaves set to different values should conflict and be owned by the winning source.",
+			first:  map[string]any{"a": "1", "b": map[string]any{"c": "2", "d": "3"}},
+			second: map[string]any{"a": "1", "b": map[string]any{"c": "4"}, "e": "5"},
+			opts:   map[string]bool{"override": true},
+			want: want{
+				conflicts: []Conflict{{Path: "b.c", Owner: "ConfigMap/first", Source: "ConfigMap/second"}},
+				owners:    map[string]string{"a": "ConfigMap/first", "b.c": "ConfigMap/second", "b.d": "ConfigMap/first", "e": "ConfigMap/second"},
+			},
+		},
+		"ConflictLostBySource": {
+			reason: "A conflict should be reported even if the merge keeps the first value.",
+			first:  map[string]any{"a": "1"},
+			second: map[string]any{"a": "2"},
+			want: want{
+				conflicts: []Conflict{{Path: "a", Owner: "ConfigMap/first", Source: "ConfigMap/second"}},
+				owners:    map[string]string{"a": "ConfigMap/first"},
+			},
+		},
+		"ListsAndObjectsDoNotConflict": {
+			reason: "Only scalar leaves should conflict.",
+			first:  map[string]any{"tags": []any{"a"}, "b": "1"},
+			second: map[string]any{"tags": []any{"b"}, "b": map[string]any{"c": "2"}},
+			opts:   map[string]bool{"override": true},
+			want: want{
+				owners: map[string]string{"tags": "ConfigMap/second", "b.c": "ConfigMap/second"},
+			},
+		},
+		"KeysWithPeriods": {
+			reason: "A key holding periods should not be confused with nested keys.",
+			first:  map[string]any{"db.host": "a", "db": map[string]any{"host": "b"}},
+			second: map[string]any{"db": map[string]any{"host": "c"}},
+			opts:   map[string]bool{"override": true},
+			want: want{
+				conflicts: []Conflict{{Path: "db.host", Owner: "ConfigMap/first", Source: "ConfigMap/second"}},
+				owners:    map[string]string{"[db.host]": "ConfigMap/first", "db.host": "ConfigMap/second"},
+			},
+		},
+		"SourceNotAMap": {
+			reason: "Sources that are not maps should never conflict.",
+			first:  map[string]any{"a": "1"},
+			second: []any{map[string]any{"op": "replace", "path": "/a", "value": "2"}},
+			want: want{
+				owners: map[string]string{"a": "ConfigMap/first"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := WithSourceOpts(Options{}, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tracker := NewTracker()
+			tracker.Record(nil, tc.first, "ConfigMap/first")
+
+			leaves := Leaves(tc.first)
+			conflicts := tracker.Conflicts(leaves, tc.second, "ConfigMap/second")
+			if diff := cmp.Diff(tc.want.conflicts, conflicts); diff != "" {
+				t.Errorf("%s\nConflicts(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if _, ok := tc.second.(map[string]any); ok {
+				merged, err := Merge(tc.first, tc.second, Config{Options: opts})
+				if err != nil {
+					t.Fatalf("%s\nMerge(...): unexpected error: %v", tc.reason, err)
+				}
+				tracker.Record(leaves, merged, "ConfigMap/second")
+			}
+			if diff := cmp.Diff(tc.want.owners, tracker.Owners()); diff != "" {
+				t.Errorf("%s\nOwners(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package redact hides sensitive values from logged resources.
+package redact
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// Redactor replaces the sensitive values of logged resources with the placeholder.
+type Redactor struct {
+	keys  []*regexp.Regexp
+	paths [][]string
+}
+
+// New creates a Redactor hiding the values of the keys matching any of the regular expressions, at any depth.
+func New(keys []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, k := range keys {
+		re, err := regexp.Compile(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid redaction pattern [%s]", k)
+		}
+		r.keys = append(r.keys, re)
+	}
+	return r, nil
+}
+
+// WithPaths returns a copy of the Redactor also hiding the values found at the field paths, e.g. `db.password` or
+// `[tls.key]`. A value that is not an object along a path is hidden as a whole, as it may embed the value at the path.
+func (r *Redactor) WithPaths(paths ...string) (*Redactor, error) {
+	out := &Redactor{keys: r.keys, paths: append([][]string{}, r.paths...)}
+	for _, p := range paths {
+		segments, err := maps.SplitPath(p)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid redaction path")
+		}
+		out.paths = append(out.paths, segments)
+	}
+	return out, nil
+}
+
+// Redact returns a copy of the object with the sensitive values replaced by the placeholder.
+func (r *Redactor) Redact(obj map[string]any) map[string]any {
+	out, _ := r.redactKeys(obj).(map[string]any)
+	for _, p := range r.paths {
+		redactPath(out, p)
+	}
+	return out
+}
+
+// redactKeys copies the value, replacing the values of matching keys.
+func (r *Redactor) redactKeys(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			if r.matches(k) {
+				out[k] = Placeholder
+				continue
+			}
+			out[k] = r.redactKeys(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = r.redactKeys(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) matches(key string) bool {
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactPath replaces the value at the path of the copied object.
+func redactPath(m map[string]any, segments []string) {
+	v, ok := m[segments[0]]
+	if !ok {
+		return
+	}
+	nm, isMap := v.(map[string]any)
+	if len(segments) == 1 || !isMap {
+		m[segments[0]] = Placeholder
+		return
+	}
+	redactPath(nm, segments[1:])
+}
+
+// Values returns a copy of the object or list with every value replaced by the placeholder. Other values are replaced
+// as a whole.
+func Values(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k := range t {
+			out[k] = Placeholder
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i := range t {
+			out[i] = Placeholder
+		}
+		return out
+	default:
+		return Placeholder
+	}
+}
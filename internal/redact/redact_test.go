@@ -0,0 +1,143 @@
+package redact
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRedact(t *testing.T) {
+	type args struct {
+		keys  []string
+		paths []string
+		obj   map[string]any
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   map[string]any
+	}{
+		"Keys": {
+			reason: "Values of keys matching a pattern should be redacted at any depth, including in lists.",
+			args: args{
+				keys: []string{`(?i)password`, `^token$`},
+				obj: map[string]any{
+					"spec": map[string]any{
+						"dbPassword": "hunter2",
+						"items":      []any{map[string]any{"token": "abc", "tokens": "kept"}},
+					},
+				},
+			},
+			want: map[string]any{
+				"spec": map[string]any{
+					"dbPassword": Placeholder,
+					"items":      []any{map[string]any{"token": Placeholder, "tokens": "kept"}},
+				},
+			},
+		},
+		"Paths": {
+			reason: "Values at the paths should be redacted and values that are not objects along a path redacted as a whole.",
+			args: args{
+				paths: []string{"data.db.password", "data.config.key", "data.missing"},
+				obj: map[string]any{
+					"data": map[string]any{
+						"db":     map[string]any{"password": "hunter2", "user": "admin"},
+						"config": "key: value",
+					},
+				},
+			},
+			want: map[string]any{
+				"data": map[string]any{
+					"db":     map[string]any{"password": Placeholder, "user": "admin"},
+					"config": Placeholder,
+				},
+			},
+		},
+		"PathsWithPeriods": {
+			reason: "Keys holding periods should be addressed between brackets.",
+			args: args{
+				paths: []string{"[tls.key]", "[.dockerconfigjson]", "app[db.password]"},
+				obj: map[string]any{
+					"tls.key":           "PRIVATE",
+					"tls.crt":           "PUBLIC",
+					".dockerconfigjson": "{}",
+					"app":               map[string]any{"db.password": "hunter2", "db.user": "admin"},
+				},
+			},
+			want: map[string]any{
+				"tls.key":           Placeholder,
+				"tls.crt":           "PUBLIC",
+				".dockerconfigjson": Placeholder,
+				"app":               map[string]any{"db.password": Placeholder, "db.user": "admin"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := New(tc.args.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := runtime.DeepCopyJSON(tc.args.obj)
+			rp, err := r.WithPaths(tc.args.paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rp.Redact(tc.args.obj)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nRedact(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(original, tc.args.obj); diff != "" {
+				t.Errorf("%s\nRedact(...): the object was modified, -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWithPathsInvalid(t *testing.T) {
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.WithPaths("data[tls.key")
+	want := "invalid redaction path: invalid path [data[tls.key]: unterminated '[' at position 4"
+	if diff := cmp.Diff(want, fmt.Sprint(err)); diff != "" {
+		t.Errorf("WithPaths(...): -want err, +got err:\n%s", diff)
+	}
+}
+
+func TestValues(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		v      any
+		want   any
+	}{
+		"Object": {
+			reason: "Every value of an object should be redacted.",
+			v:      map[string]any{"a": "1", "b": map[string]any{"c": "2"}},
+			want:   map[string]any{"a": Placeholder, "b": Placeholder},
+		},
+		"List": {
+			reason: "Every element of a list should be redacted.",
+			v:      []any{"a", map[string]any{"b": "1"}},
+			want:   []any{Placeholder, Placeholder},
+		},
+		"Scalar": {
+			reason: "A scalar should be redacted as a whole.",
+			v:      "a",
+			want:   Placeholder,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Values(tc.v)); diff != "" {
+				t.Errorf("%s\nValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
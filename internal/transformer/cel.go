@@ -0,0 +1,147 @@
+package transformer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// celCostLimit bounds the cost of evaluating an expression, so that a runaway expression cannot stall the function.
+const celCostLimit = 1000000
+
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("data", cel.DynType),
+		cel.Variable("xr", cel.DynType),
+		cel.Variable("environment", cel.DynType),
+		ext.Strings(),
+		ext.Encoders(),
+		ext.Math(),
+		ext.Lists(),
+		ext.Sets(),
+	)
+})
+
+// CEL sets the keys of the object data to the result of CEL expressions, the arguments mapping the field path of each
+// key to its expression, e.g. `url: '"https://" + data.host + ":" + string(data.port)'`. Keys holding periods are
+// written between brackets, e.g. `[app.url]`. Expressions are evaluated against the data before the transform as
+// `data`, the observed composite resource as `xr` and the environment as `environment`, so their order does not
+// matter. Evaluating an expression costing more than the cost limit is an error.
+func CEL(ctx Context, data any, args map[string]string) (any, error) {
+	m, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object data, got %T", data)
+	}
+	env, err := celEnv()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CEL environment: %w", err)
+	}
+
+	paths := make([]string, 0, len(args))
+	for path := range args {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	vars := map[string]any{
+		"data":        m,
+		"xr":          mapOrEmpty(ctx.Composite),
+		"environment": mapOrEmpty(ctx.Environment),
+	}
+	segments := make(map[string][]string, len(paths))
+	results := make(map[string]any, len(paths))
+	for _, path := range paths {
+		if segments[path], err = maps.SplitPath(path); err != nil {
+			return nil, fmt.Errorf("invalid key of CEL expression: %w", err)
+		}
+		ast, iss := env.Compile(args[path])
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("cannot compile CEL expression of key [%s]: %w", path, iss.Err())
+		}
+		prg, err := env.Program(ast, cel.CostLimit(celCostLimit))
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile CEL expression of key [%s]: %w", path, err)
+		}
+		out, _, err := prg.Eval(vars)
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate CEL expression of key [%s]: %w", path, err)
+		}
+		v, err := celValue(out)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert result of CEL expression of key [%s]: %w", path, err)
+		}
+		results[path] = v
+	}
+
+	out := copyMap(m)
+	for _, path := range paths {
+		if err := setPath(out, segments[path], results[path]); err != nil {
+			return nil, fmt.Errorf("cannot set key [%s]: %w", path, err)
+		}
+	}
+	return out, nil
+}
+
+// celValue converts the result of an expression to an unstructured value. Integers are kept as int64, as JSON numbers
+// would render large ones with an exponent, e.g. `1e+06`.
+func celValue(out ref.Val) (any, error) {
+	switch out.Type() {
+	case types.IntType:
+		return out.Value(), nil
+	case types.UintType:
+		u := out.Value().(uint64)
+		if u > math.MaxInt64 {
+			return nil, fmt.Errorf("unsigned integer %d overflows int64", u)
+		}
+		return int64(u), nil
+	}
+	native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, err
+	}
+	return native.(*structpb.Value).AsInterface(), nil
+}
+
+// setPath sets the value at the path, creating the missing objects along it and copying the existing ones.
+func setPath(m map[string]any, path []string, v any) error {
+	if len(path) == 1 {
+		m[path[0]] = v
+		return nil
+	}
+	var next map[string]any
+	switch cur := m[path[0]].(type) {
+	case nil:
+		next = make(map[string]any)
+	case map[string]any:
+		next = copyMap(cur)
+	default:
+		return fmt.Errorf("[%s] holds a value of type %T, expected an object", path[0], cur)
+	}
+	m[path[0]] = next
+	return setPath(next, path[1:], v)
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func mapOrEmpty(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
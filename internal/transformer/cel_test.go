@@ -0,0 +1,150 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCEL(t *testing.T) {
+	ctx := Context{
+		Composite:   map[string]any{"metadata": map[string]any{"name": "my-xr"}},
+		Environment: map[string]any{"region": "eu-west-1"},
+	}
+
+	type args struct {
+		data any
+		args map[string]string
+	}
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ComputedKeys": {
+			reason: "Expressions should compute new keys and rewrite existing ones from the data before the transform.",
+			args: args{
+				data: map[string]any{"host": "db", "port": int64(5432), "replicas": int64(1)},
+				args: map[string]string{
+					"url":      `"https://" + data.host + ":" + string(data.port)`,
+					"replicas": `data.replicas + 1`,
+					"host":     `data.host.upperAscii()`,
+				},
+			},
+			want: want{out: map[string]any{"host": "DB", "port": int64(5432), "replicas": int64(2), "url": "https://db:5432"}},
+		},
+		"Numbers": {
+			reason: "Integer results should be kept as integers and other numbers returned as JSON numbers.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{
+					"int":    `1000 * 1000`,
+					"uint":   `1000000u`,
+					"double": `1.5 * 2.0`,
+				},
+			},
+			want: want{out: map[string]any{"int": int64(1000000), "uint": int64(1000000), "double": float64(3)}},
+		},
+		"UintOverflow": {
+			reason: "An unsigned integer overflowing int64 should be an error.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{"big": `18446744073709551615u`},
+			},
+			want: want{err: "cannot convert result of CEL expression of key [big]: unsigned integer 18446744073709551615 overflows int64"},
+		},
+		"NestedKeys": {
+			reason: "Nested keys should be set, creating the missing objects, from the XR and the environment.",
+			args: args{
+				data: map[string]any{"owner": map[string]any{"team": "core"}},
+				args: map[string]string{
+					"owner.name":     `xr.metadata.name`,
+					"location.zones": `[environment.region + "a", environment.region + "b"]`,
+				},
+			},
+			want: want{out: map[string]any{
+				"owner":    map[string]any{"team": "core", "name": "my-xr"},
+				"location": map[string]any{"zones": []any{"eu-west-1a", "eu-west-1b"}},
+			}},
+		},
+		"KeysWithPeriods": {
+			reason: "Keys holding periods should be set as is when written between brackets.",
+			args: args{
+				data: map[string]any{"app": map[string]any{"name": "web"}},
+				args: map[string]string{
+					"[app.url]":        `"https://" + data.app.name`,
+					"app[tls.enabled]": `true`,
+				},
+			},
+			want: want{out: map[string]any{
+				"app":     map[string]any{"name": "web", "tls.enabled": true},
+				"app.url": "https://web",
+			}},
+		},
+		"InvalidKey": {
+			reason: "A key that is not a valid field path should be an error.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{"app[url": `"x"`},
+			},
+			want: want{err: "invalid key of CEL expression: invalid path [app[url]: unterminated '[' at position 3"},
+		},
+		"CostLimitExceeded": {
+			reason: "An expression exceeding the cost limit should be an error.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{"n": `[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(a, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(b, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(c, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(d, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(e, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(f, a + b + c + d + e + f))))))`},
+			},
+			want: want{err: "cannot evaluate CEL expression of key [n]: operation cancelled: actual cost limit exceeded"},
+		},
+		"NotAnObjectAlongPath": {
+			reason: "Setting a key under a value that is not an object should be an error.",
+			args: args{
+				data: map[string]any{"owner": "core"},
+				args: map[string]string{"owner.name": `xr.metadata.name`},
+			},
+			want: want{err: "cannot set key [owner.name]: [owner] holds a value of type string, expected an object"},
+		},
+		"InvalidExpression": {
+			reason: "An expression that does not compile should be an error.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{"url": `"https://" +`},
+			},
+			want: want{err: "cannot compile CEL expression of key [url]: ERROR: <input>:1:13: Syntax error: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}\n | \"https://\" +\n | ............^"},
+		},
+		"MissingKey": {
+			reason: "An expression referencing a missing key should be an error.",
+			args: args{
+				data: map[string]any{},
+				args: map[string]string{"url": `data.host`},
+			},
+			want: want{err: "cannot evaluate CEL expression of key [url]: no such key: host"},
+		},
+		"ListData": {
+			reason: "Data that is not an object should be an error.",
+			args: args{
+				data: []any{},
+				args: map[string]string{"url": `"https://"`},
+			},
+			want: want{err: "expected object data, got []interface {}"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := CEL(ctx, tc.args.data, tc.args.args)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nCEL(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nCEL(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
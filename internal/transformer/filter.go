@@ -0,0 +1,73 @@
+package transformer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FilterKeys keeps the top-level keys of the object data matching any include pattern, all keys when there are none,
+// and drops the ones matching any exclude pattern. Patterns are globs, e.g. `feature.*`, or regular expressions when
+// wrapped in slashes, e.g. `/^internal\./`.
+func FilterKeys(data any, include, exclude []string) (any, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return data, nil
+	}
+	m, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot filter keys of data of type %T, expected an object", data)
+	}
+	includeFns, err := keyMatchers(include)
+	if err != nil {
+		return nil, err
+	}
+	excludeFns, err := keyMatchers(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if len(includeFns) > 0 && !matchAny(includeFns, k) {
+			continue
+		}
+		if matchAny(excludeFns, k) {
+			continue
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func keyMatchers(patterns []string) ([]func(string) bool, error) {
+	out := make([]func(string) bool, 0, len(patterns))
+	for _, p := range patterns {
+		if len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid key pattern [%s]: %w", p, err)
+			}
+			out = append(out, re.MatchString)
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid key pattern [%s]: %w", p, err)
+		}
+		glob := p
+		out = append(out, func(k string) bool {
+			ok, _ := path.Match(glob, k)
+			return ok
+		})
+	}
+	return out, nil
+}
+
+func matchAny(fns []func(string) bool, k string) bool {
+	for _, fn := range fns {
+		if fn(k) {
+			return true
+		}
+	}
+	return false
+}
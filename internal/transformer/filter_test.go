@@ -0,0 +1,80 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterKeys(t *testing.T) {
+	data := map[string]any{
+		"feature.a":  "1",
+		"feature.b":  "2",
+		"internal.c": "3",
+		"other":      "4",
+	}
+
+	type args struct {
+		data    any
+		include []string
+		exclude []string
+	}
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoPatterns": {
+			reason: "Data should be returned as is without patterns.",
+			args:   args{data: []any{"a"}},
+			want:   want{out: []any{"a"}},
+		},
+		"IncludeGlob": {
+			reason: "Only keys matching an include glob should be kept.",
+			args:   args{data: data, include: []string{"feature.*", "other"}},
+			want:   want{out: map[string]any{"feature.a": "1", "feature.b": "2", "other": "4"}},
+		},
+		"ExcludeRegex": {
+			reason: "Keys matching an exclude regular expression should be dropped.",
+			args:   args{data: data, exclude: []string{`/^internal\./`}},
+			want:   want{out: map[string]any{"feature.a": "1", "feature.b": "2", "other": "4"}},
+		},
+		"IncludeThenExclude": {
+			reason: "Excluded keys should be dropped from the included ones.",
+			args:   args{data: data, include: []string{"feature.*"}, exclude: []string{"*.b"}},
+			want:   want{out: map[string]any{"feature.a": "1"}},
+		},
+		"InvalidGlob": {
+			reason: "An invalid glob should be an error.",
+			args:   args{data: data, include: []string{"feature.["}},
+			want:   want{err: "invalid key pattern [feature.[]: syntax error in pattern"},
+		},
+		"InvalidRegex": {
+			reason: "An invalid regular expression should be an error.",
+			args:   args{data: data, exclude: []string{"/(/"}},
+			want:   want{err: "invalid key pattern [/(/]: error parsing regexp: missing closing ): `(`"},
+		},
+		"ListData": {
+			reason: "Filtering data that is not an object should be an error.",
+			args:   args{data: []any{"a"}, include: []string{"*"}},
+			want:   want{err: "cannot filter keys of data of type []interface {}, expected an object"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := FilterKeys(tc.args.data, tc.args.include, tc.args.exclude)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nFilterKeys(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nFilterKeys(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package transformer
+
+import (
+	"fmt"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/maps"
+)
+
+// MountAt nests the data under the field path, e.g. `sources.a`. Keys holding periods are written between brackets,
+// e.g. `sources[team.a]`.
+func MountAt(data any, path string) (any, error) {
+	if path == "" {
+		return data, nil
+	}
+	segments, err := maps.SplitPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mount path: %w", err)
+	}
+	for i := len(segments) - 1; i >= 0; i-- {
+		data = map[string]any{segments[i]: data}
+	}
+	return data, nil
+}
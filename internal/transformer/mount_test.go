@@ -0,0 +1,57 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMountAt(t *testing.T) {
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		data   any
+		path   string
+		want   want
+	}{
+		"Unmounted": {
+			reason: "Data should be returned as is without a path.",
+			data:   map[string]any{"a": "1"},
+			want:   want{out: map[string]any{"a": "1"}},
+		},
+		"Nested": {
+			reason: "Data should be nested under every key of the path.",
+			data:   []any{"a"},
+			path:   "sources.a",
+			want:   want{out: map[string]any{"sources": map[string]any{"a": []any{"a"}}}},
+		},
+		"KeysWithPeriods": {
+			reason: "Keys holding periods should be nested under as is when written between brackets.",
+			data:   map[string]any{"a": "1"},
+			path:   "sources[team.a]",
+			want:   want{out: map[string]any{"sources": map[string]any{"team.a": map[string]any{"a": "1"}}}},
+		},
+		"EmptyKey": {
+			reason: "A path with an empty key should be an error.",
+			data:   map[string]any{"a": "1"},
+			path:   "sources..a",
+			want:   want{err: "invalid mount path: invalid path [sources..a]: unexpected '.' at position 8"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := MountAt(tc.data, tc.path)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nMountAt(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nMountAt(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package transformer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Context holds the data available to transforms besides the transformed data.
+type Context struct {
+	// Composite is the observed composite resource.
+	Composite map[string]any
+	// Environment is the environment of the composition, if any.
+	Environment map[string]any
+}
+
+// Func transforms the data, an object or a list, using the arguments of its step.
+type Func func(ctx Context, data any, args map[string]string) (any, error)
+
+// Step is a transform applied by name with its arguments.
+type Step struct {
+	Name string
+	Args map[string]string
+}
+
+var registry = map[string]Func{
+	"stringToMap": mapTransform(TransformToMap),
+	"mapToString": mapTransform(TransformFromMap),
+	"template":    Template,
+	"cel":         CEL,
+}
+
+// Register adds a transform to the registry, replacing any transform registered under the same name.
+func Register(name string, fn Func) {
+	registry[name] = fn
+}
+
+// Registered returns the names of the registered transforms in sorted order.
+func Registered() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply runs the steps in order, each one transforming the output of the previous one.
+func Apply(ctx Context, data any, steps []Step) (any, error) {
+	for i, step := range steps {
+		fn, ok := registry[step.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transform [%s] at step %d, expected one of %v", step.Name, i, Registered())
+		}
+		out, err := fn(ctx, data, step.Args)
+		if err != nil {
+			return nil, fmt.Errorf("transform [%s] at step %d failed: %w", step.Name, i, err)
+		}
+		switch out.(type) {
+		case map[string]any, []any:
+		default:
+			return nil, fmt.Errorf("transform [%s] at step %d returned data of type %T, expected an object or a list", step.Name, i, out)
+		}
+		data = out
+	}
+	return data, nil
+}
+
+// mapTransform adapts a transform of objects to the registry, applying it to objects and the objects of lists.
+func mapTransform(fn func(map[string]any) map[string]any) Func {
+	return func(_ Context, data any, _ map[string]string) (any, error) {
+		switch d := data.(type) {
+		case map[string]any:
+			return fn(d), nil
+		case []any:
+			out := make([]any, 0, len(d))
+			for _, v := range d {
+				if vm, ok := v.(map[string]any); ok {
+					v = fn(vm)
+				}
+				out = append(out, v)
+			}
+			return out, nil
+		default:
+			return data, nil
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package transformer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApply(t *testing.T) {
+	Register("test-append", func(_ Context, data any, args map[string]string) (any, error) {
+		m, ok := data.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object")
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = fmt.Sprint(v) + args["suffix"]
+		}
+		return out, nil
+	})
+	Register("test-scalar", func(_ Context, _ any, _ map[string]string) (any, error) {
+		return "scalar", nil
+	})
+
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		data   any
+		steps  []Step
+		want   want
+	}{
+		"Ordered": {
+			reason: "Steps should run in order, each one transforming the output of the previous one.",
+			data:   map[string]any{"a": "x"},
+			steps: []Step{
+				{Name: "test-append", Args: map[string]string{"suffix": "1"}},
+				{Name: "test-append", Args: map[string]string{"suffix": "2"}},
+			},
+			want: want{out: map[string]any{"a": "x12"}},
+		},
+		"StringToMapList": {
+			reason: "Object transforms should apply to the objects of lists.",
+			data:   []any{map[string]any{"a": "b: c"}, "d"},
+			steps:  []Step{{Name: "stringToMap"}},
+			want:   want{out: []any{map[string]any{"a": map[string]any{"b": "c"}}, "d"}},
+		},
+		"Unknown": {
+			reason: "An unknown transform should be an error.",
+			data:   map[string]any{},
+			steps:  []Step{{Name: "stringToMap"}, {Name: "missing"}},
+			want:   want{err: "unknown transform [missing] at step 1, expected one of " + fmt.Sprint(Registered())},
+		},
+		"Failed": {
+			reason: "A failing transform should be an error.",
+			data:   []any{},
+			steps:  []Step{{Name: "test-append"}},
+			want:   want{err: "transform [test-append] at step 0 failed: expected an object"},
+		},
+		"NotObjectOrList": {
+			reason: "A transform returning data that is neither an object nor a list should be an error.",
+			data:   map[string]any{},
+			steps:  []Step{{Name: "test-scalar"}},
+			want:   want{err: "transform [test-scalar] at step 0 returned data of type string, expected an object or a list"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Apply(Context{}, tc.data, tc.steps)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nApply(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
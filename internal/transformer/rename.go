@@ -0,0 +1,39 @@
+package transformer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RenameKeys renames the top-level keys of the object data found in rename and adds the prefix and suffix to the
+// other keys. Two keys renamed to the same key is an error.
+func RenameKeys(data any, rename map[string]string, prefix, suffix string) (any, error) {
+	if len(rename) == 0 && prefix == "" && suffix == "" {
+		return data, nil
+	}
+	m, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot rename keys of data of type %T, expected an object", data)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]any, len(m))
+	from := make(map[string]string, len(m))
+	for _, k := range keys {
+		name, ok := rename[k]
+		if !ok {
+			name = prefix + k + suffix
+		}
+		if prev, ok := from[name]; ok {
+			return nil, fmt.Errorf("keys [%s] and [%s] are both renamed to [%s]", prev, k, name)
+		}
+		from[name] = k
+		out[name] = m[k]
+	}
+	return out, nil
+}
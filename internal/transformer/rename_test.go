@@ -0,0 +1,64 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenameKeys(t *testing.T) {
+	type args struct {
+		data   any
+		rename map[string]string
+		prefix string
+		suffix string
+	}
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Unchanged": {
+			reason: "Data should be returned as is without renaming.",
+			args:   args{data: []any{"a"}},
+			want:   want{out: []any{"a"}},
+		},
+		"PrefixAndSuffix": {
+			reason: "The prefix and suffix should be added to every key.",
+			args:   args{data: map[string]any{"host": "db", "port": "5432"}, prefix: "db.", suffix: "_value"},
+			want:   want{out: map[string]any{"db.host_value": "db", "db.port_value": "5432"}},
+		},
+		"Rename": {
+			reason: "Renamed keys should not be prefixed.",
+			args:   args{data: map[string]any{"host": "db", "port": "5432"}, rename: map[string]string{"host": "endpoint"}, prefix: "db."},
+			want:   want{out: map[string]any{"endpoint": "db", "db.port": "5432"}},
+		},
+		"Collision": {
+			reason: "Two keys renamed to the same key should be an error.",
+			args:   args{data: map[string]any{"host": "db", "db.host": "cache"}, prefix: "db.", rename: map[string]string{"db.host": "db.host"}},
+			want:   want{err: "keys [db.host] and [host] are both renamed to [db.host]"},
+		},
+		"ListData": {
+			reason: "Renaming keys of data that is not an object should be an error.",
+			args:   args{data: []any{"a"}, prefix: "db."},
+			want:   want{err: "cannot rename keys of data of type []interface {}, expected an object"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := RenameKeys(tc.args.data, tc.args.rename, tc.args.prefix, tc.args.suffix)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nRenameKeys(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nRenameKeys(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
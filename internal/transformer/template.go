@@ -0,0 +1,86 @@
+package transformer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// funcs holds the sprig functions without `env` and `expandenv`, which would expose the environment variables of the
+// function.
+var funcs = func() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	return f
+}()
+
+// Template renders the string values of the data, at any depth, as Go templates with the sprig functions. Templates
+// are executed against the observed composite resource as `.xr`, the environment as `.environment` and the
+// transformed data as `.data`. The `leftDelim` and `rightDelim` arguments override the `{{` and `}}` delimiters.
+// Referencing a missing key is an error.
+func Template(ctx Context, data any, args map[string]string) (any, error) {
+	left, right := args["leftDelim"], args["rightDelim"]
+	if left == "" {
+		left = "{{"
+	}
+	if right == "" {
+		right = "}}"
+	}
+	values := map[string]any{
+		"xr":          ctx.Composite,
+		"environment": ctx.Environment,
+		"data":        data,
+	}
+	render := func(path, text string) (string, error) {
+		if !strings.Contains(text, left) {
+			return text, nil
+		}
+		tmpl, err := template.New(path).Delims(left, right).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("cannot parse template of key [%s]: %w", path, err)
+		}
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return "", fmt.Errorf("cannot render template of key [%s]: %w", path, err)
+		}
+		return buf.String(), nil
+	}
+	return renderValues(data, "", render)
+}
+
+// renderValues returns a copy of the value with its strings rendered, keyed by their dot separated path.
+func renderValues(v any, path string, render func(path, text string) (string, error)) (any, error) {
+	switch val := v.(type) {
+	case string:
+		return render(path, val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			r, err := renderValues(e, p, render)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, 0, len(val))
+		for i, e := range val {
+			r, err := renderValues(e, fmt.Sprintf("%s[%d]", path, i), render)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
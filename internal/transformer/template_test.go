@@ -0,0 +1,95 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplate(t *testing.T) {
+	ctx := Context{
+		Composite:   map[string]any{"metadata": map[string]any{"name": "my-xr", "labels": map[string]any{"team": "core"}}},
+		Environment: map[string]any{"region": "eu-west-1"},
+	}
+
+	type args struct {
+		data any
+		args map[string]string
+	}
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Context": {
+			reason: "Templates should render the XR, the environment, the data and the sprig functions at any depth.",
+			args: args{
+				data: map[string]any{
+					"host":  "db",
+					"url":   `https://{{ .data.host }}.{{ .environment.region }}`,
+					"owner": map[string]any{"name": `{{ .xr.metadata.name | upper }}`},
+					"tags":  []any{`{{ .xr.metadata.labels.team }}`, 1},
+				},
+			},
+			want: want{out: map[string]any{
+				"host":  "db",
+				"url":   "https://db.eu-west-1",
+				"owner": map[string]any{"name": "MY-XR"},
+				"tags":  []any{"core", 1},
+			}},
+		},
+		"Delims": {
+			reason: "The delimiters should be configurable, leaving other braces untouched.",
+			args: args{
+				data: map[string]any{"v": `[[ .xr.metadata.name ]] {{ .Values.name }}`},
+				args: map[string]string{"leftDelim": "[[", "rightDelim": "]]"},
+			},
+			want: want{out: map[string]any{"v": "my-xr {{ .Values.name }}"}},
+		},
+		"EnvUnavailable": {
+			reason: "The sprig functions reading environment variables should not be available.",
+			args: args{
+				data: map[string]any{"home": `{{ env "HOME" }}`},
+			},
+			want: want{err: `cannot parse template of key [home]: template: home:1: function "env" not defined`},
+		},
+		"ExpandEnvUnavailable": {
+			reason: "The sprig functions reading environment variables should not be available.",
+			args: args{
+				data: map[string]any{"home": `{{ expandenv "$HOME" }}`},
+			},
+			want: want{err: `cannot parse template of key [home]: template: home:1: function "expandenv" not defined`},
+		},
+		"MissingKey": {
+			reason: "Referencing a missing key should be an error.",
+			args: args{
+				data: []any{map[string]any{"v": `{{ .data.missing }}`}},
+			},
+			want: want{err: `cannot render template of key [[0].v]: template: [0].v:1:8: executing "[0].v" at <.data.missing>: can't evaluate field missing in type interface {}`},
+		},
+		"InvalidTemplate": {
+			reason: "An invalid template should be an error.",
+			args: args{
+				data: map[string]any{"v": `{{ .xr.metadata.name`},
+			},
+			want: want{err: `cannot parse template of key [v]: template: v:1: unclosed action`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Template(ctx, tc.args.data, tc.args.args)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nTemplate(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nTemplate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -2,22 +2,25 @@
 package transformer
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
 
 	"github.com/crossplane/function-sdk-go/resource"
 )
 
 type io = map[string]any
 
-var transformerMap = map[string]func(io) io{
-	"stringToMap": TransformToMap,
-}
+// legacyTransforms lists the transforms the XR `spec.transform` map can enable, in application order. Other
+// transforms are only applied as ordered steps.
+var legacyTransforms = []string{"stringToMap"}
 
 // Transform parses a given XR composite and applies any found settings by running the appropriate transformer.
+// Only the legacy transforms can be enabled, others are ignored.
 func Transform(xr *resource.Composite, in io) (io, error) {
 	type xrSpec struct {
 		Spec struct {
@@ -25,23 +28,27 @@ func Transform(xr *resource.Composite, in io) (io, error) {
 		}
 	}
 
-	out := in
 	var xrConfig xrSpec
 	//nolint: nilerr // Silently ignore when transform settings are not set
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(xr.Resource.Object, &xrConfig); err != nil {
 		return in, nil
 	}
 
-	for opt, enabled := range xrConfig.Spec.Transform {
-		if !enabled {
-			continue
-		}
-		if transformFn, ok := transformerMap[opt]; ok {
-			out = transformFn(out)
+	var steps []Step
+	for _, name := range legacyTransforms {
+		if xrConfig.Spec.Transform[name] {
+			steps = append(steps, Step{Name: name})
 		}
 	}
-
-	return out, nil
+	out, err := Apply(Context{Composite: xr.Resource.Object}, in, steps)
+	if err != nil {
+		return nil, err
+	}
+	outMap, ok := out.(io)
+	if !ok {
+		return nil, fmt.Errorf("transformed data of type %T, expected an object", out)
+	}
+	return outMap, nil
 }
 
 // TransformToMap transforms all map values to other maps when possible.
@@ -76,19 +83,101 @@ func TransformFromMap(a map[string]any) map[string]any {
 	return outData
 }
 
-// ExtractMapValue extracts a map value where its key matches the provided argument.
+// ExtractMapValue extracts a map value where its key matches the provided argument, searching nested maps at any
+// depth. Values that are not maps are returned under their key. Finding the key more than once is an error.
 func ExtractMapValue(m map[string]any, key string) (map[string]any, error) {
+	matches := findMapValues(m, key, nil)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unable to find value for key [%s]", key)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("found %d values for key [%s], expected exactly one", len(matches), key)
+	}
+}
+
+func findMapValues(m map[string]any, key string, matches []map[string]any) []map[string]any {
 	for k, v := range m {
 		vm, ok := v.(map[string]any)
 		if k == key {
 			if ok {
-				return vm, nil
+				matches = append(matches, vm)
+			} else {
+				matches = append(matches, map[string]any{k: v})
 			}
-			return map[string]any{k: v}, nil
+			continue
 		}
 		if ok {
-			return ExtractMapValue(vm, key)
+			matches = findMapValues(vm, key, matches)
 		}
 	}
-	return nil, fmt.Errorf("unable to find value for key [%s]", key)
+	return matches
+}
+
+// ExtractPath extracts the object or list selected by a JSONPath expression, e.g. `{.spec.values}` or
+// `.items[?(@.name=="app")].config`. The expression must match exactly one value.
+func ExtractPath(data any, expr string) (any, error) {
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	jp := jsonpath.New("extract").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression [%s]: %w", expr, err)
+	}
+	results, err := jp.FindResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to evaluate JSONPath expression [%s]: %w", expr, err)
+	}
+
+	var values []any
+	for _, result := range results {
+		for _, v := range result {
+			values = append(values, v.Interface())
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("no value matched JSONPath expression [%s]", expr)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%d values matched JSONPath expression [%s], expected exactly one", len(values), expr)
+	}
+
+	switch v := values[0].(type) {
+	case map[string]any, []any:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("JSONPath expression [%s] matched a value of type %T, expected an object or a list", expr, v)
+	}
+}
+
+// DecodeSecretData decodes the base64 encoded values of a Secret data map and overlays its stringData, as the API
+// server does when writing a Secret.
+func DecodeSecretData(data, stringData map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(data)+len(stringData))
+	for k, v := range data {
+		vs, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret data value for key [%s] is not a string", k)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(vs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode secret data value for key [%s]: %w", k, err)
+		}
+		out[k] = string(decoded)
+	}
+	for k, v := range stringData {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// EncodeSecretData transforms all map values to base64 encoded strings, map values being encoded as YAML first.
+func EncodeSecretData(a map[string]any) map[string]any {
+	outData := TransformFromMap(a)
+	for k, v := range outData {
+		outData[k] = base64.StdEncoding.EncodeToString([]byte(v.(string)))
+	}
+	return outData
 }
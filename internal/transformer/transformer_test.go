@@ -0,0 +1,179 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func TestTransform(t *testing.T) {
+	cases := map[string]struct {
+		reason    string
+		transform map[string]any
+		in        map[string]any
+		want      map[string]any
+	}{
+		"StringToMap": {
+			reason:    "Enabling stringToMap should decode string values.",
+			transform: map[string]any{"stringToMap": true},
+			in:        map[string]any{"a": "b: c"},
+			want:      map[string]any{"a": map[string]any{"b": "c"}},
+		},
+		"OnlyLegacyTransforms": {
+			reason:    "Transforms other than the legacy ones should not be enabled.",
+			transform: map[string]any{"stringToMap": true, "mapToString": true, "cel": true, "template": true},
+			in:        map[string]any{"a": "b: c", "n": int64(1)},
+			want:      map[string]any{"a": map[string]any{"b": "c"}, "n": int64(1)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			xr := &resource.Composite{
+				Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+					"spec": map[string]any{"transform": tc.transform},
+				}}},
+			}
+			out, err := Transform(xr, tc.in)
+			if err != nil {
+				t.Fatalf("%s\nTransform(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, out); diff != "" {
+				t.Errorf("%s\nTransform(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExtractMapValue(t *testing.T) {
+	type want struct {
+		out map[string]any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		m      map[string]any
+		key    string
+		want   want
+	}{
+		"NestedSibling": {
+			reason: "A key nested under a sibling of other keys should be found.",
+			m: map[string]any{
+				"a": map[string]any{"x": "1"},
+				"b": map[string]any{"values": map[string]any{"y": "2"}},
+			},
+			key:  "values",
+			want: want{out: map[string]any{"y": "2"}},
+		},
+		"ScalarValue": {
+			reason: "A value that is not a map should be returned under its key.",
+			m:      map[string]any{"values": "v"},
+			key:    "values",
+			want:   want{out: map[string]any{"values": "v"}},
+		},
+		"NotFound": {
+			reason: "A missing key should be an error.",
+			m:      map[string]any{"a": "1"},
+			key:    "values",
+			want:   want{err: "unable to find value for key [values]"},
+		},
+		"Ambiguous": {
+			reason: "A key found more than once should be an error.",
+			m: map[string]any{
+				"a": map[string]any{"values": map[string]any{"x": "1"}},
+				"b": map[string]any{"values": map[string]any{"y": "2"}},
+			},
+			key:  "values",
+			want: want{err: "found 2 values for key [values], expected exactly one"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := ExtractMapValue(tc.m, tc.key)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nExtractMapValue(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nExtractMapValue(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExtractPath(t *testing.T) {
+	data := map[string]any{
+		"spec": map[string]any{
+			"items": []any{
+				map[string]any{"name": "db", "values": map[string]any{"port": "5432"}},
+				map[string]any{"name": "app", "values": map[string]any{"replicas": "2"}},
+			},
+		},
+	}
+
+	type want struct {
+		out any
+		err string
+	}
+
+	cases := map[string]struct {
+		reason string
+		expr   string
+		want   want
+	}{
+		"Object": {
+			reason: "An expression matching an object should return it.",
+			expr:   "{.spec.items[0].values}",
+			want:   want{out: map[string]any{"port": "5432"}},
+		},
+		"Filter": {
+			reason: "A filter expression without braces should select the matching element.",
+			expr:   `.spec.items[?(@.name=="app")].values`,
+			want:   want{out: map[string]any{"replicas": "2"}},
+		},
+		"List": {
+			reason: "An expression matching a list should return it.",
+			expr:   ".spec.items",
+			want:   want{out: data["spec"].(map[string]any)["items"]},
+		},
+		"NoMatch": {
+			reason: "An expression matching nothing should be an error.",
+			expr:   ".spec.missing",
+			want:   want{err: "no value matched JSONPath expression [{.spec.missing}]"},
+		},
+		"MultipleMatches": {
+			reason: "An expression matching several values should be an error.",
+			expr:   ".spec.items[*].values",
+			want:   want{err: "2 values matched JSONPath expression [{.spec.items[*].values}], expected exactly one"},
+		},
+		"Scalar": {
+			reason: "An expression matching a scalar should be an error.",
+			expr:   ".spec.items[0].name",
+			want:   want{err: "JSONPath expression [{.spec.items[0].name}] matched a value of type string, expected an object or a list"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := ExtractPath(data, tc.expr)
+			if diff := cmp.Diff(tc.want.err, errString(err)); diff != "" {
+				t.Errorf("%s\nExtractPath(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nExtractPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
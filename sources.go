@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/input/v1alpha1"
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/k8s"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+)
+
+var (
+	namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	secretGVK    = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+)
+
+// source is a resolved source resource alongside the reference that selected it.
+type source struct {
+	ref      v1alpha1.SourceRef
+	resource *unstructured.Unstructured
+}
+
+// String returns a human-readable identifier of the source resource, e.g. `ConfigMap/namespace/name`. Resources
+// without a namespace are identified as `Kind/name`.
+func (s source) String() string {
+	if ns := s.resource.GetNamespace(); ns != "" {
+		return fmt.Sprintf("%s/%s/%s", s.resource.GetKind(), ns, s.resource.GetName())
+	}
+	return fmt.Sprintf("%s/%s", s.resource.GetKind(), s.resource.GetName())
+}
+
+// sourceRefKey returns the extra resources requirement key of the source reference at the given index.
+func sourceRefKey(i int) string {
+	return fmt.Sprintf("sourceRefs[%d]", i)
+}
+
+// extraResourcesRequirements returns the extra resources Crossplane must fetch for each source reference.
+func extraResourcesRequirements(refs []v1alpha1.SourceRef) *fnv1beta1.Requirements {
+	out := &fnv1beta1.Requirements{ExtraResources: make(map[string]*fnv1beta1.ResourceSelector, len(refs))}
+	for i, ref := range refs {
+		if ref.Selector == nil {
+			out.ExtraResources[sourceRefKey(i)] = &fnv1beta1.ResourceSelector{
+				ApiVersion: ref.Ref.APIVersion,
+				Kind:       ref.Ref.Kind,
+				Match:      &fnv1beta1.ResourceSelector_MatchName{MatchName: ref.Ref.Name},
+			}
+			continue
+		}
+
+		// Crossplane only matches labels, expressions are evaluated once the resources are fetched.
+		out.ExtraResources[sourceRefKey(i)] = matchLabelsSelector(ref.Ref.APIVersion, ref.Ref.Kind, ref.Selector)
+		if ref.NamespaceSelector != nil {
+			out.ExtraResources[sourceRefKey(i)+".namespaces"] = matchLabelsSelector(namespaceGVK.GroupVersion().String(), namespaceGVK.Kind, ref.NamespaceSelector)
+		}
+	}
+	return out
+}
+
+func matchLabelsSelector(apiVersion, kind string, selector *metav1.LabelSelector) *fnv1beta1.ResourceSelector {
+	return &fnv1beta1.ResourceSelector{
+		ApiVersion: apiVersion,
+		Kind:       kind,
+		Match: &fnv1beta1.ResourceSelector_MatchLabels{
+			MatchLabels: &fnv1beta1.MatchLabels{Labels: selector.MatchLabels},
+		},
+	}
+}
+
+// describeSourceRef returns a human-readable identifier of the source reference.
+func describeSourceRef(ref v1alpha1.SourceRef) string {
+	if ref.Selector == nil {
+		return fmt.Sprintf("%s/%s", ref.Ref.Kind, ref.Ref.Name)
+	}
+	return fmt.Sprintf("%s/[%s]", ref.Ref.Kind, metav1.FormatLabelSelector(ref.Selector))
+}
+
+// resolveSourceRef returns the resources referenced by the source reference in merge order.
+func resolveSourceRef(ctx context.Context, reader k8s.Reader, ref v1alpha1.SourceRef, typeMeta metav1.TypeMeta) ([]*unstructured.Unstructured, error) {
+	gvk := ref.Ref.GroupVersionKind()
+	if ref.Selector == nil {
+		res, err := reader.GetResource(ctx, ref.Namespace, ref.Ref.Name, gvk, metav1.GetOptions{
+			TypeMeta: typeMeta,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{res}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(ref.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid selector")
+	}
+	items, err := reader.ListResources(ctx, ref.Namespace, gvk, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.NamespaceSelector != nil {
+		nsSelector, err := metav1.LabelSelectorAsSelector(ref.NamespaceSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid namespace selector")
+		}
+		namespaces, err := reader.ListResources(ctx, "", namespaceGVK, nsSelector)
+		if err != nil {
+			return nil, err
+		}
+		allowed := make(map[string]bool, len(namespaces))
+		for _, ns := range namespaces {
+			allowed[ns.GetName()] = true
+		}
+		filtered := items[:0]
+		for _, item := range items {
+			if allowed[item.GetNamespace()] {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	if len(items) == 0 {
+		return nil, noMatchError{selector: selector}
+	}
+	return items, sortSources(items, ref.OrderBy)
+}
+
+// noMatchError reports a selector matching no resources.
+type noMatchError struct {
+	selector labels.Selector
+}
+
+// Error returns a description of the selector.
+func (e noMatchError) Error() string {
+	return fmt.Sprintf("no resources matched selector [%s]", e.selector)
+}
+
+// isMissing returns true if the error reports a source resource that cannot be found.
+func isMissing(err error) bool {
+	var nm noMatchError
+	return errors.As(err, &nm) || kerrors.IsNotFound(err)
+}
+
+// missingSourcePolicy returns the policy handling the source reference when it cannot be found.
+// Optional sources are skipped with a warning unless missing sources are skipped silently.
+func missingSourcePolicy(ref v1alpha1.SourceRef, policy v1alpha1.MissingSourcePolicy) v1alpha1.MissingSourcePolicy {
+	if policy == "" {
+		policy = v1alpha1.MissingSourceFail
+	}
+	if ref.Optional && policy == v1alpha1.MissingSourceFail {
+		return v1alpha1.MissingSourceWarn
+	}
+	return policy
+}
+
+// sortSources sorts the resources in merge order. Resources merged last take precedence.
+func sortSources(items []*unstructured.Unstructured, by v1alpha1.OrderBy) error {
+	// Sorting by name first guarantees a stable order for resources sharing the same priority or timestamp.
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	switch by {
+	case "", v1alpha1.OrderByName:
+		return nil
+	case v1alpha1.OrderByCreationTimestamp:
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].GetCreationTimestamp().Time.Before(items[j].GetCreationTimestamp().Time)
+		})
+		return nil
+	case v1alpha1.OrderByPriority:
+		priorities := make(map[*unstructured.Unstructured]int, len(items))
+		for _, item := range items {
+			value, ok := item.GetAnnotations()[v1alpha1.PriorityAnnotation]
+			if !ok {
+				continue
+			}
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid %s annotation on %s/%s", v1alpha1.PriorityAnnotation, item.GetKind(), item.GetName())
+			}
+			priorities[item] = priority
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return priorities[items[i]] < priorities[items[j]]
+		})
+		return nil
+	default:
+		return errors.Errorf("unsupported order [%s]", by)
+	}
+}
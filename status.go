@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/pcanilho/crossplane-function-resources-merger/input/v1alpha1"
+	"github.com/pcanilho/crossplane-function-resources-merger/internal/merger"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+	"github.com/crossplane/function-sdk-go/request"
+	"github.com/crossplane/function-sdk-go/response"
+)
+
+// statusPath is the field of the composite resource holding the merge details.
+const statusPath = "status.merge"
+
+// setCompositeStatus sets the fields under the merge status and the conditions of the desired composite resource.
+func setCompositeStatus(req *fnv1beta1.RunFunctionRequest, rsp *fnv1beta1.RunFunctionResponse, fields map[string]any, conditions ...xpv1.Condition) error {
+	xr, err := request.GetDesiredCompositeResource(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get desired composite resource from %T", req)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := xr.Resource.SetValue(statusPath+"."+k, fields[k]); err != nil {
+			return errors.Wrapf(err, "cannot set %s.%s", statusPath, k)
+		}
+	}
+	observed, err := request.GetObservedCompositeResource(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get observed composite resource from %T", req)
+	}
+	xr.Resource.SetConditions(withObservedTransitions(observed.Resource, conditions)...)
+	return errors.Wrapf(response.SetDesiredCompositeResource(rsp, xr), "cannot set desired composite resource in %T", rsp)
+}
+
+// sourcesStatus converts the merged sources to their status representation, in merge order.
+func sourcesStatus(sources []source) []any {
+	out := make([]any, 0, len(sources))
+	for _, src := range sources {
+		out = append(out, map[string]any{
+			"apiVersion":      src.resource.GetAPIVersion(),
+			"kind":            src.resource.GetKind(),
+			"name":            src.resource.GetName(),
+			"namespace":       src.resource.GetNamespace(),
+			"resourceVersion": src.resource.GetResourceVersion(),
+		})
+	}
+	return out
+}
+
+// targetStatus converts the target reference to its status representation.
+func targetStatus(target v1alpha1.SourceRef) map[string]any {
+	return map[string]any{
+		"apiVersion": target.Ref.APIVersion,
+		"kind":       target.Ref.Kind,
+		"name":       target.Ref.Name,
+		"namespace":  target.Namespace,
+	}
+}
+
+// dataHash returns the hex encoded SHA-256 hash of the JSON encoding of the merged data.
+// Map keys are encoded in sorted order, so equal data always produces the same hash.
+func dataHash(data any) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal merged data")
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// conflictsStatus converts the conflicts to their status representation.
+func conflictsStatus(conflicts []merger.Conflict) []any {
+	out := make([]any, 0, len(conflicts))
+	for _, c := range conflicts {
+		out = append(out, map[string]any{
+			"path":    c.Path,
+			"sources": []any{c.Owner, c.Source},
+		})
+	}
+	return out
+}
+
+// provenanceStatus converts the leaf owners to their status representation.
+func provenanceStatus(owners map[string]string) map[string]any {
+	out := make(map[string]any, len(owners))
+	for path, owner := range owners {
+		out[path] = owner
+	}
+	return out
+}